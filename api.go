@@ -0,0 +1,145 @@
+package simpleforce
+
+import (
+	"context"
+)
+
+// Querier runs SOQL queries.
+type Querier interface {
+	// QueryContext runs an SOQL query, or fetches the next records of a query from its nextRecordsURL.
+	QueryContext(ctx context.Context, q string) (*QueryResult, error)
+	// QueryAllContext runs an SOQL query including the deleted and archived records, or fetches the next records of
+	// such a query from its nextRecordsURL.
+	QueryAllContext(ctx context.Context, q string) (*QueryResult, error)
+}
+
+// RecordDML creates, retrieves, updates, upserts and deletes records. Record fields are set without the attributes
+// and Id fields.
+type RecordDML interface {
+	// GetRecordContext retrieves the record of the type with the ID.
+	GetRecordContext(ctx context.Context, typeName, id string) (*SObject, error)
+	// CreateRecordContext creates a record of the type, and returns its ID.
+	CreateRecordContext(ctx context.Context, typeName string, fields map[string]interface{}) (string, error)
+	// UpdateRecordContext updates the fields of the record of the type with the ID.
+	UpdateRecordContext(ctx context.Context, typeName, id string, fields map[string]interface{}) error
+	// UpsertRecordContext creates or updates the record of the type whose external ID field matches the value set in
+	// fields.
+	UpsertRecordContext(ctx context.Context, typeName, externalIDField string, fields map[string]interface{}) (*SaveResult, error)
+	// DeleteRecordContext deletes the record of the type with the ID.
+	DeleteRecordContext(ctx context.Context, typeName, id string) error
+}
+
+// Describer describes the objects of the organization.
+type Describer interface {
+	// DescribeGlobalContext lists the objects of the organization.
+	DescribeGlobalContext(ctx context.Context) (*SObjectMeta, error)
+	// DescribeSObjectContext describes the object of the type.
+	DescribeSObjectContext(ctx context.Context, typeName string) (*SObjectMeta, error)
+}
+
+// ToolingAPI runs the calls of the Tooling API.
+type ToolingAPI interface {
+	// ToolingQueryContext runs an SOQL query against the Tooling API.
+	ToolingQueryContext(ctx context.Context, q string) (*QueryResult, error)
+	// ExecuteAnonymousContext executes a body of Apex code.
+	ExecuteAnonymousContext(ctx context.Context, apexBody string) (*ExecuteAnonymousResult, error)
+}
+
+// API gathers the calls made to salesforce, so that code depending on it rather than on *Client can be tested
+// against a fake, such as sftest.Fake.
+type API interface {
+	Querier
+	RecordDML
+	Describer
+	ToolingAPI
+}
+
+var _ API = (*Client)(nil)
+
+// GetRecord retrieves the record of the type with the ID.
+func (client *Client) GetRecord(typeName, id string) (*SObject, error) {
+	return client.GetRecordContext(context.Background(), typeName, id)
+}
+
+// GetRecordContext retrieves the record of the type with the ID with the context.
+func (client *Client) GetRecordContext(ctx context.Context, typeName, id string) (*SObject, error) {
+	obj := client.SObject(typeName)
+	err := obj.GetContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// CreateRecord creates a record of the type, and returns its ID.
+func (client *Client) CreateRecord(typeName string, fields map[string]interface{}) (string, error) {
+	return client.CreateRecordContext(context.Background(), typeName, fields)
+}
+
+// CreateRecordContext creates a record of the type with the context, and returns its ID.
+func (client *Client) CreateRecordContext(ctx context.Context, typeName string, fields map[string]interface{}) (string, error) {
+	obj := client.SObject(typeName)
+	obj.SetMany(fields)
+	err := obj.CreateContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return obj.ID(), nil
+}
+
+// UpdateRecord updates the fields of the record of the type with the ID.
+func (client *Client) UpdateRecord(typeName, id string, fields map[string]interface{}) error {
+	return client.UpdateRecordContext(context.Background(), typeName, id, fields)
+}
+
+// UpdateRecordContext updates the fields of the record of the type with the ID with the context.
+func (client *Client) UpdateRecordContext(ctx context.Context, typeName, id string, fields map[string]interface{}) error {
+	obj := client.SObject(typeName)
+	obj.SetMany(fields)
+	obj.setID(id)
+	return obj.UpdateContext(ctx)
+}
+
+// UpsertRecord creates or updates the record of the type whose external ID field matches the value set in fields.
+func (client *Client) UpsertRecord(typeName, externalIDField string, fields map[string]interface{}) (*SaveResult, error) {
+	return client.UpsertRecordContext(context.Background(), typeName, externalIDField, fields)
+}
+
+// UpsertRecordContext creates or updates the record of the type whose external ID field matches the value set in
+// fields with the context.
+func (client *Client) UpsertRecordContext(ctx context.Context, typeName, externalIDField string, fields map[string]interface{}) (*SaveResult, error) {
+	obj := client.SObject(typeName)
+	obj.SetMany(fields)
+	return obj.UpsertWithResultContext(ctx, externalIDField)
+}
+
+// DeleteRecord deletes the record of the type with the ID.
+func (client *Client) DeleteRecord(typeName, id string) error {
+	return client.DeleteRecordContext(context.Background(), typeName, id)
+}
+
+// DeleteRecordContext deletes the record of the type with the ID with the context.
+func (client *Client) DeleteRecordContext(ctx context.Context, typeName, id string) error {
+	return client.SObject(typeName).DeleteContext(ctx, id)
+}
+
+// DescribeSObject describes the object of the type.
+func (client *Client) DescribeSObject(typeName string) (*SObjectMeta, error) {
+	return client.DescribeSObjectContext(context.Background(), typeName)
+}
+
+// DescribeSObjectContext describes the object of the type with the context.
+func (client *Client) DescribeSObjectContext(ctx context.Context, typeName string) (*SObjectMeta, error) {
+	return client.SObject(typeName).DescribeContext(ctx)
+}
+
+// ToolingQuery runs an SOQL query against the Tooling API. Unlike Tooling().Query(q), it doesn't switch the client to
+// the Tooling API.
+func (client *Client) ToolingQuery(q string) (*QueryResult, error) {
+	return client.ToolingQueryContext(context.Background(), q)
+}
+
+// ToolingQueryContext runs an SOQL query against the Tooling API with the context.
+func (client *Client) ToolingQueryContext(ctx context.Context, q string) (*QueryResult, error) {
+	return client.query(withOperation(ctx, "ToolingQuery", ResourceQuery, ""), "tooling/query", q)
+}
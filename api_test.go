@@ -0,0 +1,60 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_RecordDML(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Contact/"
+	httpmock.RegisterResponder("POST", mockURL, httpmock.NewStringResponder(201, `{"id": "003xx", "success": true, "errors": []}`))
+	httpmock.RegisterResponder("GET", mockURL+"003xx", httpmock.NewStringResponder(200,
+		`{"attributes": {"type": "Contact", "url": "/services/data/v43.0/sobjects/Contact/003xx"}, "Id": "003xx", "LastName": "Doe"}`))
+	var updated bool
+	httpmock.RegisterResponder("PATCH", mockURL+"003xx", func(req *http.Request) (*http.Response, error) {
+		updated = true
+		return httpmock.NewStringResponse(204, ""), nil
+	})
+	httpmock.RegisterResponder("DELETE", mockURL+"003xx", httpmock.NewStringResponder(204, ""))
+
+	var api API = client
+	ctx := context.Background()
+	id, err := api.CreateRecordContext(ctx, "Contact", map[string]interface{}{"LastName": "Doe"})
+	if err != nil || id != "003xx" {
+		t.Fatal(id, err)
+	}
+	obj, err := api.GetRecordContext(ctx, "Contact", id)
+	if err != nil || obj.StringField("LastName") != "Doe" {
+		t.Fatal(obj, err)
+	}
+	if err := api.UpdateRecordContext(ctx, "Contact", id, map[string]interface{}{"FirstName": "Jane"}); err != nil || !updated {
+		t.Fatal(err)
+	}
+	if err := api.DeleteRecordContext(ctx, "Contact", id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_ToolingQuery(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/tooling/query?q=SELECT%20Id%20FROM%20ApexClass",
+		httpmock.NewStringResponder(200, `{"totalSize": 1, "done": true, "records": [{"Id": "01pxx"}]}`))
+
+	result, err := client.ToolingQuery("SELECT Id FROM ApexClass")
+	if err != nil || result.TotalSize != 1 {
+		t.Fatal(result, err)
+	}
+	if client.useToolingAPI {
+		t.Fail()
+	}
+}
@@ -0,0 +1,398 @@
+// Package cassette records the HTTP traffic of a simpleforce client into scrubbed cassette files, and replays them
+// so that tests run deterministically without network access:
+//
+//	recorder, err := cassette.New("testdata/query.json", cassette.ModeReplayOrRecord)
+//	client.SetHttpClient(recorder.Client())
+//	// ... use the client
+//	err = recorder.Stop()
+//
+// Session IDs, tokens, passwords and the hosts of the instance are redacted from the recorded traffic, as well as the
+// values of the fields set with RedactFields.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/simpleforce/simpleforce/internal/secrets"
+)
+
+// Mode selects whether a Recorder records or replays the traffic.
+type Mode int
+
+const (
+	// ModeReplay replays the cassette, and fails the requests which weren't recorded.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests to salesforce and records them, replacing the cassette.
+	ModeRecord
+	// ModeReplayOrRecord replays the cassette if it exists, and records it otherwise.
+	ModeReplayOrRecord
+)
+
+// Redacted replaces the redacted values in cassettes.
+const Redacted = "REDACTED"
+
+// RedactedHost replaces the hosts of the instance in cassettes.
+const RedactedHost = "instance.example.my.salesforce.com"
+
+// ErrInteractionNotFound is returned in replay mode for the requests which aren't in the cassette.
+var ErrInteractionNotFound = errors.New("interaction not found in cassette")
+
+// defaultKeptHosts lists the hosts which aren't redacted, so that logins are replayed from the same URL.
+var defaultKeptHosts = []string{"login.salesforce.com", "test.salesforce.com"}
+
+// keptHeaders lists the headers kept in cassettes, the others being dropped.
+var keptHeaders = []string{"Content-Type", "Sforce-Limit-Info", "Sforce-Auto-Assign", "Sforce-Duplicate-Rule-Header",
+	"Sforce-Mru", "Sforce-Call-Options", "Sforce-Query-Options", "Soapaction", "Location"}
+
+// instanceURLPattern matches the instance URLs returned by SOAP and OAuth logins.
+var instanceURLPattern = regexp.MustCompile(`(?:<(?:\w+:)?(?:serverUrl|metadataServerUrl)>|"instance_url"\s*:\s*")(https?://[^/<"]+)`)
+
+// Cassette holds the recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	replayed bool
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette.
+type Recorder struct {
+	// Transport sends the requests in record mode. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+
+	mu        sync.Mutex
+	path      string
+	recording bool
+	cassette  *Cassette
+	keptHosts map[string]bool
+	// hosts lists the instance hosts redacted from the recorded traffic.
+	hosts  map[string]bool
+	fields map[string]bool
+}
+
+// New creates a Recorder for the cassette file at path. In replay mode, the cassette is loaded.
+func New(path string, mode Mode) (*Recorder, error) {
+	recorder := &Recorder{
+		path:      path,
+		cassette:  &Cassette{},
+		keptHosts: make(map[string]bool),
+		hosts:     make(map[string]bool),
+		fields:    make(map[string]bool),
+	}
+	for _, host := range defaultKeptHosts {
+		recorder.keptHosts[host] = true
+	}
+
+	_, err := os.Stat(path)
+	switch {
+	case mode == ModeRecord || (mode == ModeReplayOrRecord && os.IsNotExist(err)):
+		recorder.recording = true
+		return recorder, nil
+	case err != nil:
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, recorder.cassette)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse cassette %s", path)
+	}
+	return recorder, nil
+}
+
+// Recording checks if the recorder is recording rather than replaying.
+func (recorder *Recorder) Recording() bool {
+	return recorder.recording
+}
+
+// KeepHosts prevents the hosts, e.g. a custom login domain, from being redacted. login.salesforce.com and
+// test.salesforce.com are always kept.
+func (recorder *Recorder) KeepHosts(hosts ...string) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, host := range hosts {
+		recorder.keptHosts[host] = true
+	}
+}
+
+// RedactFields redacts the values of the fields, e.g. "Email", wherever they appear in JSON bodies. The values in
+// request URLs, such as the WHERE clause of a query, aren't redacted: tests recording a cassette should not filter on
+// the redacted fields.
+func (recorder *Recorder) RedactFields(fields ...string) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, field := range fields {
+		recorder.fields[field] = true
+	}
+}
+
+// Client returns an HTTP client using the recorder, to be set with simpleforce's Client.SetHttpClient.
+func (recorder *Recorder) Client() *http.Client {
+	return &http.Client{Transport: recorder}
+}
+
+// RoundTrip records or replays the request.
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if recorder.recording {
+		return recorder.record(req, body)
+	}
+	return recorder.replay(req)
+}
+
+// record sends the request and records the scrubbed interaction. The response is returned as received.
+func (recorder *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := recorder.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.learnHosts(req.URL.Host, respBody)
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     recorder.scrub(req.URL.String()),
+			Headers: scrubHeaders(req.Header),
+			Body:    recorder.scrub(string(body)),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    scrubHeaders(resp.Header),
+			Body:       recorder.scrub(string(respBody)),
+		},
+	})
+	return resp, nil
+}
+
+// replay returns the response of the first interaction not replayed yet matching the method and URL of the request.
+func (recorder *Recorder) replay(req *http.Request) (*http.Response, error) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	reqURL := recorder.scrub(req.URL.String())
+	for _, interaction := range recorder.cassette.Interactions {
+		if interaction.replayed || interaction.Request.Method != req.Method || interaction.Request.URL != reqURL {
+			continue
+		}
+		interaction.replayed = true
+
+		header := http.Header{}
+		for key, values := range interaction.Response.Headers {
+			header[key] = append([]string(nil), values...)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Wrapf(ErrInteractionNotFound, "%s %s", req.Method, reqURL)
+}
+
+// Stop saves the cassette in record mode. The file is written with 0600 permissions.
+func (recorder *Recorder) Stop() error {
+	if !recorder.recording {
+		return nil
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	data, err := marshal(recorder.cassette, "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(recorder.path, data, 0600)
+}
+
+// learnHosts adds the host of the request, and the instance hosts returned by logins, to the hosts to redact.
+func (recorder *Recorder) learnHosts(host string, respBody []byte) {
+	if !recorder.keptHosts[host] {
+		recorder.hosts[host] = true
+	}
+	for _, match := range instanceURLPattern.FindAllSubmatch(respBody, -1) {
+		u, err := url.Parse(string(match[1]))
+		if err == nil && u.Host != "" && !recorder.keptHosts[u.Host] {
+			recorder.hosts[u.Host] = true
+		}
+	}
+}
+
+// scrub redacts the credentials, the instance hosts and the values of the redacted fields in s.
+func (recorder *Recorder) scrub(s string) string {
+	for host := range recorder.hosts {
+		s = strings.Replace(s, host, RedactedHost, -1)
+	}
+	s = secrets.Redact(s, Redacted)
+	if len(recorder.fields) == 0 {
+		return s
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var buf bytes.Buffer
+	if recorder.redactFields(decoder, &buf, false) != nil {
+		return s
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return s
+	}
+	return buf.String()
+}
+
+// redactFields copies the next JSON value from the decoder to buf, replacing the values of the redacted fields. The
+// value itself is replaced, unless null, if redacted is set. The keys are kept in order and the numbers as they were
+// written, so that only the redacted values differ from the original body.
+func (recorder *Recorder) redactFields(decoder *json.Decoder, buf *bytes.Buffer, redacted bool) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		if redacted && token != nil {
+			token = Redacted
+		}
+		data, err := marshal(token, "")
+		buf.Write(data)
+		return err
+	}
+
+	if redacted {
+		// Skip the nested object or array.
+		for depth := 1; depth > 0; {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			switch token {
+			case json.Delim('{'), json.Delim('['):
+				depth++
+			case json.Delim('}'), json.Delim(']'):
+				depth--
+			}
+		}
+		data, err := marshal(Redacted, "")
+		buf.Write(data)
+		return err
+	}
+
+	buf.WriteString(delim.String())
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		field := false
+		if delim == '{' {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			data, err := marshal(key, "")
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			buf.WriteByte(':')
+			field = recorder.fields[key.(string)]
+		}
+		if err := recorder.redactFields(decoder, buf, field); err != nil {
+			return err
+		}
+	}
+	token, err = decoder.Token()
+	if err != nil {
+		return err
+	}
+	buf.WriteString(token.(json.Delim).String())
+	return nil
+}
+
+// scrubHeaders returns the kept headers, with credentials redacted.
+func scrubHeaders(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for _, key := range keptHeaders {
+		if values, ok := header[http.CanonicalHeaderKey(key)]; ok {
+			scrubbed[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
+
+// marshal encodes v without escaping HTML characters, so that XML bodies remain readable in cassettes.
+func marshal(v interface{}, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	err := encoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,146 @@
+package cassette_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/simpleforce/simpleforce"
+	"github.com/simpleforce/simpleforce/cassette"
+)
+
+const (
+	testSessionID = "00Dxx0000001gPL!AQ4AQFb1x.2kVlvZsecret"
+	testPassword  = "s3cr3t-p4ss"
+)
+
+// newFakeSalesforce starts a server answering the SOAP login of the instance na99.my.salesforce.com and a query.
+func newFakeSalesforce(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.Contains(req.URL.Path, "/services/Soap/u/"):
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="utf-8" ?>
+				<env:Envelope><env:Body><env:loginResponse><env:result>
+					<env:serverUrl>https://na99.my.salesforce.com/services/Soap/u/43.0</env:serverUrl>
+					<env:sessionId>` + testSessionID + `</env:sessionId>
+					<env:userId>005xx000001SvxJAAS</env:userId>
+				</env:result></env:loginResponse></env:Body></env:Envelope>`))
+		case strings.HasSuffix(req.URL.Path, "/query"):
+			if req.Header.Get("Authorization") != "Bearer "+testSessionID {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Sforce-Limit-Info", "api-usage=12/15000")
+			w.Header().Set("Set-Cookie", "sid="+testSessionID)
+			w.Write([]byte(`{"totalSize": 1, "done": true, "records": [{"attributes": {"type": "Contact",
+				"url": "/services/data/v43.0/sobjects/Contact/003xx000004TmiQAAS"}, "Id": "003xx000004TmiQAAS",
+				"LastName": "Doe", "Email": "jane.doe@example.com", "Amount__c": 123456789012345678}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// redirectTransport sends every request to the server, whatever its host.
+type redirectTransport struct {
+	server *url.URL
+}
+
+func (transport redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = transport.server.Scheme
+	req.URL.Host = transport.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func queryContacts(t *testing.T, recorder *cassette.Recorder) *simpleforce.QueryResult {
+	client := simpleforce.NewClient(simpleforce.DefaultURL, simpleforce.DefaultClientID, simpleforce.DefaultAPIVersion)
+	client.SetHttpClient(recorder.Client())
+	if err := client.LoginPassword("user@example.com", testPassword, ""); err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.Query("SELECT Id, LastName, Email FROM Contact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRecorder(t *testing.T) {
+	server := newFakeSalesforce(t)
+	serverURL, _ := url.Parse(server.URL)
+	path := filepath.Join(t.TempDir(), "contacts.json")
+
+	recorder, err := cassette.New(path, cassette.ModeReplayOrRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorder.Recording() {
+		t.Fatal("expected to record a missing cassette")
+	}
+	recorder.Transport = redirectTransport{server: serverURL}
+	recorder.RedactFields("Email")
+	result := queryContacts(t, recorder)
+	if result.Records[0].StringField("Email") != "jane.doe@example.com" {
+		t.Fail()
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"AQ4AQFb1x", testPassword, "na99", "jane.doe", "Set-Cookie"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	// Only the redacted field differs from the response: the keys are in order and the numbers aren't rounded.
+	var recorded cassette.Cassette
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	body := recorded.Interactions[len(recorded.Interactions)-1].Response.Body
+	if !strings.Contains(body, `"LastName":"Doe","Email":"REDACTED","Amount__c":123456789012345678}]}`) {
+		t.Error(body)
+	}
+
+	// Replay without the server.
+	server.Close()
+	recorder, err = cassette.New(path, cassette.ModeReplayOrRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Recording() {
+		t.Fatal("expected to replay the cassette")
+	}
+	result = queryContacts(t, recorder)
+	if len(result.Records) != 1 || result.Records[0].StringField("LastName") != "Doe" ||
+		result.Records[0].StringField("Email") != cassette.Redacted {
+		t.Fail()
+	}
+
+	// Every interaction has been replayed.
+	_, err = recorder.Client().Get("https://" + cassette.RedactedHost + "/services/data/v43.0/limits")
+	if !errors.Is(err, cassette.ErrInteractionNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestNew_missingCassette(t *testing.T) {
+	if _, err := cassette.New(filepath.Join(t.TempDir(), "missing.json"), cassette.ModeReplay); err == nil {
+		t.Fail()
+	}
+}
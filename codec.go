@@ -0,0 +1,483 @@
+package simpleforce
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DateLayout is the layout of salesforce date fields.
+	DateLayout = "2006-01-02"
+	// DateTimeLayout is the layout of salesforce datetime fields.
+	DateTimeLayout = "2006-01-02T15:04:05.000-0700"
+	// TimeLayout is the layout of salesforce time fields.
+	TimeLayout = "15:04:05.000Z"
+)
+
+// timeLayouts lists the layouts tried in order to parse dates, datetimes and times.
+var timeLayouts = []string{DateTimeLayout, time.RFC3339Nano, DateLayout, TimeLayout}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structField is a struct field mapped to a salesforce field with the sf tag.
+type structField struct {
+	index []int
+	// path is the salesforce field, split on the relationships, e.g. ["Account", "Name"].
+	path      []string
+	omitEmpty bool
+	readOnly  bool
+	date      bool
+}
+
+// structFields returns the fields of the struct type mapped to salesforce fields. The sf tag holds the salesforce
+// field name, "-" to ignore the field, and the following options after a comma:
+//   - omitempty: the field isn't encoded if it has a zero value.
+//   - readonly: the field is decoded but never encoded, e.g. for formula fields or relationships.
+//   - date: the time.Time field is encoded as a date rather than a datetime.
+//
+// Fields without tag are mapped to the salesforce field of the same name. The fields of embedded structs are mapped as
+// if they were fields of the struct.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("sf")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			for _, embedded := range structFields(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, options := tag, ""
+		if i := strings.Index(tag, ","); i != -1 {
+			name, options = tag[:i], tag[i+1:]
+		}
+		if name == "" {
+			name = f.Name
+		}
+		field := structField{index: f.Index, path: strings.Split(name, ".")}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				field.omitEmpty = true
+			case "readonly":
+				field.readOnly = true
+			case "date":
+				field.date = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Decode decodes the fields of the SObject into v, a pointer to a struct whose fields are mapped to salesforce fields
+// with the sf tag, e.g.:
+//
+//	type Contact struct {
+//		ID          string     `sf:"Id,readonly"`
+//		LastName    string
+//		AccountName string     `sf:"Account.Name,readonly"`
+//		Birthdate   *time.Time `sf:",date"`
+//		Cases       []Case     `sf:"Cases"`
+//	}
+//
+// Relationship fields are decoded from nested objects, either with a dotted path or into nested structs, and child
+// subqueries into slices. Dates, datetimes and times are decoded into time.Time fields, and multi-select picklists into
+// []string fields. Null values leave pointers nil and other fields zero. The fields missing from the SObject are left
+// untouched.
+func (obj *SObject) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("unable to decode into %T: a pointer to a struct is required", v)
+	}
+	return decodeStruct(*obj, rv.Elem())
+}
+
+// Decode decodes the records into v, a pointer to a slice of structs or of pointers to structs. See SObject.Decode for
+// the mapping of the fields.
+func (result *QueryResult) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("unable to decode into %T: a pointer to a slice is required", v)
+	}
+
+	slice := rv.Elem()
+	records := reflect.MakeSlice(slice.Type(), len(result.Records), len(result.Records))
+	for i, record := range result.Records {
+		err := decodeValue(map[string]interface{}(record), records.Index(i))
+		if err != nil {
+			return errors.Wrapf(err, "unable to decode record %d", i)
+		}
+	}
+	slice.Set(records)
+	return nil
+}
+
+// Encode sets the fields of the SObject from v, a struct or a pointer to a struct mapped with the sf tag. See
+// SObject.Decode for the mapping. Fields with a dotted path or a nested struct are encoded as nested objects, which
+// salesforce accepts to reference a related record by external ID. Slices of structs (child relationships) and
+// readonly fields aren't encoded. Nil pointers and zero time.Time values are encoded as null, clearing the field,
+// unless the field is omitempty.
+func (obj *SObject) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.New("unable to encode a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.Errorf("unable to encode %T: a struct is required", v)
+	}
+
+	fields, err := encodeStruct(rv)
+	if err != nil {
+		return err
+	}
+	obj.SetMany(fields)
+	return nil
+}
+
+// Decode decodes the current record into v. See SObject.Decode.
+func (it *QueryIter) Decode(v interface{}) error {
+	if it.record == nil {
+		return errors.New("no current record")
+	}
+	return it.record.Decode(v)
+}
+
+// decodeStruct decodes the fields of the record into v, a struct.
+func decodeStruct(record map[string]interface{}, v reflect.Value) error {
+	for _, field := range structFields(v.Type()) {
+		value, ok := lookupField(record, field.path)
+		if !ok {
+			continue
+		}
+		err := decodeValue(value, v.FieldByIndex(field.index))
+		if err != nil {
+			return errors.Wrapf(err, "unable to decode %s", strings.Join(field.path, "."))
+		}
+	}
+	return nil
+}
+
+// lookupField returns the value at the path in the record, following the relationships. A null relationship gives a
+// null value. Field names are matched case insensitively if there is no exact match.
+func lookupField(record map[string]interface{}, path []string) (interface{}, bool) {
+	for i, name := range path {
+		value, ok := record[name]
+		if !ok {
+			for key, v := range record {
+				if strings.EqualFold(key, name) {
+					value, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok || i == len(path)-1 || value == nil {
+			return value, ok
+		}
+		record, ok = asMap(value)
+		if !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// asMap returns the value as a map if it is a JSON object or an SObject.
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case SObject:
+		return v, true
+	case *SObject:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return nil, false
+}
+
+// decodeValue decodes a value of a record, as decoded from JSON, into v.
+func decodeValue(value interface{}, v reflect.Value) error {
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		err := decodeValue(value, elem.Elem())
+		if err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case v.Type() == timeType:
+		s, ok := value.(string)
+		if !ok {
+			return errors.Errorf("unable to decode %T into time.Time", value)
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		switch value := value.(type) {
+		case string:
+			v.SetString(value)
+		case float64:
+			v.SetString(strconv.FormatFloat(value, 'f', -1, 64))
+		case json.Number:
+			v.SetString(value.String())
+		case bool:
+			v.SetString(strconv.FormatBool(value))
+		default:
+			return errors.Errorf("unable to decode %T into %s", value, v.Type())
+		}
+	case reflect.Bool:
+		switch value := value.(type) {
+		case bool:
+			v.SetBool(value)
+		case string:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+		default:
+			return errors.Errorf("unable to decode %T into %s", value, v.Type())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return errors.Errorf("unable to decode %v into %s", i, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		d, err := toDecimal(value)
+		if err != nil {
+			return err
+		}
+		if !d.IsInt() || d.Sign() < 0 || !d.Num().IsUint64() || v.OverflowUint(d.Num().Uint64()) {
+			return errors.Errorf("unable to decode %v into %s", value, v.Type())
+		}
+		v.SetUint(d.Num().Uint64())
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+		if v.OverflowFloat(f) {
+			return errors.Errorf("unable to decode %v into %s", f, v.Type())
+		}
+		v.SetFloat(f)
+	case reflect.Struct:
+		record, ok := asMap(value)
+		if !ok {
+			return errors.Errorf("unable to decode %T into %s", value, v.Type())
+		}
+		return decodeStruct(record, v)
+	case reflect.Slice:
+		return decodeSlice(value, v)
+	case reflect.Map, reflect.Interface:
+		rv := reflect.ValueOf(value)
+		if record, ok := asMap(value); ok && v.Kind() == reflect.Map {
+			rv = reflect.ValueOf(record)
+		}
+		switch {
+		case rv.Type().AssignableTo(v.Type()):
+			v.Set(rv)
+		case rv.Type().ConvertibleTo(v.Type()):
+			v.Set(rv.Convert(v.Type()))
+		default:
+			return errors.Errorf("unable to decode %T into %s", value, v.Type())
+		}
+	default:
+		return errors.Errorf("unable to decode into unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// decodeSlice decodes the records of a child subquery, a JSON array or a multi-select picklist into v, a slice.
+func decodeSlice(value interface{}, v reflect.Value) error {
+	if s, ok := value.(string); ok && v.Type().Elem().Kind() == reflect.String {
+		values := reflect.MakeSlice(v.Type(), 0, 0)
+		if s != "" {
+			for _, item := range strings.Split(s, ";") {
+				values = reflect.Append(values, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(values)
+		return nil
+	}
+
+	if record, ok := asMap(value); ok {
+		// Child subqueries are returned as query results.
+		value = record["records"]
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return errors.Errorf("unable to decode %T into %s", value, v.Type())
+	}
+	values := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		err := decodeValue(item, values.Index(i))
+		if err != nil {
+			return errors.Wrapf(err, "unable to decode item %d", i)
+		}
+	}
+	v.Set(values)
+	return nil
+}
+
+// toFloat returns a number, or a string holding a number, as float64.
+func toFloat(value interface{}) (float64, error) {
+	switch value := value.(type) {
+	case float64:
+		return value, nil
+	case json.Number:
+		return value.Float64()
+	case string:
+		return strconv.ParseFloat(value, 64)
+	}
+	return 0, errors.Errorf("unable to decode %T into a number", value)
+}
+
+// toDecimal returns a number, or a string holding a number, as an exact decimal.
+func toDecimal(value interface{}) (*big.Rat, error) {
+	var text string
+	switch value := value.(type) {
+	case float64:
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	case json.Number:
+		text = value.String()
+	case string:
+		text = value
+	default:
+		return nil, errors.Errorf("unable to decode %T into a decimal", value)
+	}
+	d, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, errors.Errorf("%q is not a decimal", text)
+	}
+	return d, nil
+}
+
+// toInt returns a number, or a string holding a number, as an integer. Numbers with a fractional part or out of the
+// int64 range are rejected.
+func toInt(value interface{}) (int64, error) {
+	d, err := toDecimal(value)
+	if err != nil {
+		return 0, err
+	}
+	if !d.IsInt() || !d.Num().IsInt64() {
+		return 0, errors.Errorf("%v is not an integer", value)
+	}
+	return d.Num().Int64(), nil
+}
+
+// parseTime parses a salesforce date, datetime or time.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("unable to parse time %q", s)
+}
+
+// encodeStruct returns the salesforce fields of v, a struct.
+func encodeStruct(v reflect.Value) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, field := range structFields(v.Type()) {
+		fv := v.FieldByIndex(field.index)
+		if field.readOnly || (field.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		value, ok, err := encodeValue(fv, field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode %s", strings.Join(field.path, "."))
+		}
+		if !ok {
+			continue
+		}
+
+		parent := fields
+		for _, name := range field.path[:len(field.path)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[name] = child
+			}
+			parent = child
+		}
+		parent[field.path[len(field.path)-1]] = value
+	}
+	return fields, nil
+}
+
+// encodeValue returns the value of a struct field as sent to salesforce, and false if the field can't be saved.
+func encodeValue(v reflect.Value, field structField) (interface{}, bool, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		switch {
+		case t.IsZero():
+			return nil, true, nil
+		case field.date:
+			return t.Format(DateLayout), true, nil
+		default:
+			return t.Format(DateTimeLayout), true, nil
+		}
+	case v.Kind() == reflect.Struct:
+		fields, err := encodeStruct(v)
+		return fields, err == nil, err
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		if v.IsNil() {
+			return nil, true, nil
+		}
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).String()
+		}
+		return strings.Join(values, ";"), true, nil
+	case v.Kind() == reflect.Slice:
+		return nil, false, nil
+	}
+	return v.Interface(), true, nil
+}
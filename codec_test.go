@@ -0,0 +1,153 @@
+package simpleforce
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type codecCase struct {
+	ID      string `sf:"Id"`
+	Subject string
+}
+
+type codecContact struct {
+	ID          string  `sf:"Id,readonly"`
+	LastName    string  `sf:",omitempty"`
+	Email       *string `sf:"Email"`
+	AccountName string  `sf:"Account.Name,readonly"`
+	Account     *struct {
+		Number string `sf:"External_Id__c"`
+	} `sf:"Account,omitempty"`
+	Birthdate    time.Time  `sf:",date"`
+	LastModified *time.Time `sf:"LastModifiedDate,readonly"`
+	Score        float64    `sf:"Score__c"`
+	Employees    int        `sf:"Employees__c"`
+	DoNotCall    bool
+	Interests    []string `sf:"Interests__c"`
+	Cases        []codecCase
+	Ignored      string `sf:"-"`
+	unexported   string
+}
+
+const codecRecord = `{
+	"attributes": {"type": "Contact"},
+	"Id": "003xx000004TmiQAAS",
+	"LastName": "Doe",
+	"Email": null,
+	"Account": {"attributes": {"type": "Account"}, "Name": "Acme", "External_Id__c": "A-1"},
+	"Birthdate": "1980-05-17",
+	"LastModifiedDate": "2021-03-04T05:06:07.000+0000",
+	"Score__c": 12.5,
+	"Employees__c": 250,
+	"DoNotCall": true,
+	"Interests__c": "Golf;Tennis",
+	"Cases": {"totalSize": 2, "done": true, "records": [
+		{"attributes": {"type": "Case"}, "Id": "500xx1", "Subject": "Broken"},
+		{"attributes": {"type": "Case"}, "Id": "500xx2", "Subject": "Late"}
+	]},
+	"Ignored": "value"
+}`
+
+func TestSObject_Decode(t *testing.T) {
+	var obj SObject
+	if err := json.Unmarshal([]byte(codecRecord), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	var contact codecContact
+	err := obj.Decode(&contact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if contact.ID != "003xx000004TmiQAAS" || contact.LastName != "Doe" || contact.Email != nil ||
+		contact.AccountName != "Acme" || contact.Account == nil || contact.Account.Number != "A-1" ||
+		!contact.Birthdate.Equal(time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC)) ||
+		contact.LastModified == nil || !contact.LastModified.Equal(modified) ||
+		contact.Score != 12.5 || contact.Employees != 250 || !contact.DoNotCall ||
+		len(contact.Interests) != 2 || contact.Interests[1] != "Tennis" ||
+		len(contact.Cases) != 2 || contact.Cases[1].Subject != "Late" || contact.Ignored != "" {
+		t.Errorf("%+v", contact)
+	}
+
+	obj["Account"] = nil
+	obj["Employees__c"] = 2.5
+	contact = codecContact{AccountName: "Old"}
+	if err := obj.Decode(&contact); err == nil {
+		t.Error("fractional number decoded into int")
+	}
+	if contact.AccountName != "" || contact.Account != nil {
+		t.Errorf("%+v", contact)
+	}
+
+	if err := obj.Decode(contact); err == nil {
+		t.Error("decoded into a non-pointer")
+	}
+}
+
+func TestQueryResult_Decode(t *testing.T) {
+	var result QueryResult
+	err := json.Unmarshal([]byte(`{"totalSize": 2, "done": true, "records": [`+codecRecord+`, {"Id": "003xx2", "LastName": "Roe"}]}`), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contacts []*codecContact
+	err = result.Decode(&contacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 2 || contacts[0].AccountName != "Acme" || contacts[1].LastName != "Roe" || contacts[1].Cases != nil {
+		t.Errorf("%+v", contacts)
+	}
+}
+
+func TestSObject_Encode(t *testing.T) {
+	email := "jane@example.com"
+	contact := codecContact{
+		ID:          "003xx1",
+		Email:       &email,
+		AccountName: "Acme",
+		Account: &struct {
+			Number string `sf:"External_Id__c"`
+		}{Number: "A-1"},
+		Birthdate: time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC),
+		Score:     12.5,
+		Interests: []string{"Golf", "Tennis"},
+		Cases:     []codecCase{{Subject: "Broken"}},
+	}
+
+	obj := &SObject{}
+	err := obj.Encode(&contact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ := (*obj)["Account"].(map[string]interface{})
+	if _, ok := (*obj)["LastName"]; ok {
+		t.Error("omitempty field encoded")
+	}
+	for _, name := range []string{"Id", "LastModifiedDate", "Account.Name", "Cases", "Ignored", "unexported"} {
+		if _, ok := (*obj)[name]; ok {
+			t.Error(name, "encoded")
+		}
+	}
+	if (*obj)["Email"] != email || account["External_Id__c"] != "A-1" || (*obj)["Birthdate"] != "1980-05-17" ||
+		(*obj)["Score__c"] != 12.5 || (*obj)["Employees__c"] != 0 || (*obj)["DoNotCall"] != false ||
+		(*obj)["Interests__c"] != "Golf;Tennis" {
+		t.Errorf("%v", *obj)
+	}
+
+	obj = &SObject{}
+	err = obj.Encode(struct {
+		Email    *string
+		Modified time.Time `sf:"LastModifiedDate"`
+		Called   time.Time `sf:"LastCalled__c"`
+	}{Called: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := (*obj)["Email"]; !ok || v != nil || (*obj)["LastModifiedDate"] != nil || (*obj)["LastCalled__c"] != "2021-03-04T05:06:07.000+0000" {
+		t.Errorf("%v", *obj)
+	}
+}
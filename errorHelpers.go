@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
-	"log"
 )
 
 var (
@@ -14,39 +15,160 @@ var (
 
 	// ErrAuthentication is returned when authentication failed.
 	ErrAuthentication = errors.New("authentication failure")
+
+	// The following errors are matched by errors.Is against an *APIError reporting the corresponding error codes.
+
+	// ErrInvalidSession matches INVALID_SESSION_ID: the session has expired or was revoked.
+	ErrInvalidSession = errors.New("invalid session")
+	// ErrDuplicateValue matches DUPLICATE_VALUE and DUPLICATES_DETECTED: a unique field or a duplicate rule rejected
+	// the record.
+	ErrDuplicateValue = errors.New("duplicate value")
+	// ErrNotFound matches NOT_FOUND and a 404 status.
+	ErrNotFound = errors.New("not found")
+	// ErrEntityDeleted matches ENTITY_IS_DELETED.
+	ErrEntityDeleted = errors.New("entity is deleted")
+	// ErrRowLocked matches UNABLE_TO_LOCK_ROW.
+	ErrRowLocked = errors.New("unable to lock row")
+	// ErrRequestLimitExceeded matches REQUEST_LIMIT_EXCEEDED.
+	ErrRequestLimitExceeded = errors.New("request limit exceeded")
+	// ErrRequiredFieldMissing matches REQUIRED_FIELD_MISSING.
+	ErrRequiredFieldMissing = errors.New("required field missing")
+	// ErrInvalidField matches the errors reporting an invalid field or field value, such as INVALID_FIELD or
+	// STRING_TOO_LONG.
+	ErrInvalidField = errors.New("invalid field")
+	// ErrValidationRule matches FIELD_CUSTOM_VALIDATION_EXCEPTION, raised by validation rules.
+	ErrValidationRule = errors.New("validation rule failed")
+	// ErrInsufficientAccess matches the errors reporting missing permissions or sharing access.
+	ErrInsufficientAccess = errors.New("insufficient access")
+	// ErrMalformedQuery matches MALFORMED_QUERY and INVALID_QUERY_FILTER_OPERATOR.
+	ErrMalformedQuery = errors.New("malformed query")
 )
 
-type jsonError []struct {
-	Message   string `json:"message"`
-	ErrorCode string `json:"errorCode"`
+// sentinelErrorCodes maps the sentinel errors to the error codes they match.
+var sentinelErrorCodes = map[error][]string{
+	ErrAuthentication:       {"INVALID_LOGIN", "LOGIN_MUST_USE_SECURITY_TOKEN", "INVALID_CLIENT_ID", "invalid_grant", "invalid_client", "invalid_client_id"},
+	ErrInvalidSession:       {"INVALID_SESSION_ID"},
+	ErrDuplicateValue:       {"DUPLICATE_VALUE", "DUPLICATES_DETECTED"},
+	ErrNotFound:             {"NOT_FOUND"},
+	ErrEntityDeleted:        {"ENTITY_IS_DELETED"},
+	ErrRowLocked:            {"UNABLE_TO_LOCK_ROW"},
+	ErrRequestLimitExceeded: {"REQUEST_LIMIT_EXCEEDED"},
+	ErrRequiredFieldMissing: {"REQUIRED_FIELD_MISSING"},
+	ErrInvalidField:         {"INVALID_FIELD", "INVALID_FIELD_FOR_INSERT_UPDATE", "INVALID_TYPE_ON_FIELD_IN_RECORD", "STRING_TOO_LONG", "INVALID_EMAIL_ADDRESS", "FIELD_INTEGRITY_EXCEPTION", "INVALID_OR_NULL_FOR_RESTRICTED_PICKLIST"},
+	ErrValidationRule:       {"FIELD_CUSTOM_VALIDATION_EXCEPTION"},
+	ErrInsufficientAccess:   {"INSUFFICIENT_ACCESS", "INSUFFICIENT_ACCESS_OR_READONLY", "INSUFFICIENT_ACCESS_ON_CROSS_REFERENCE_ENTITY", "API_DISABLED_FOR_ORG"},
+	ErrMalformedQuery:       {"MALFORMED_QUERY", "INVALID_QUERY_FILTER_OPERATOR"},
+}
+
+// APIError is the error returned when salesforce responds with an error status. It holds every error reported in the
+// response, in the JSON (REST), OAuth or SOAP fault format. Use errors.Is with the sentinel errors, such as
+// ErrInvalidSession, to check for common errors.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Errors lists the errors reported in the response. It is empty if the response could not be parsed.
+	Errors []APIErrorEntry
+	// Body is the raw response data.
+	Body []byte
+}
+
+// APIErrorEntry is one error reported by salesforce.
+type APIErrorEntry struct {
+	Message   string   `json:"message"`
+	ErrorCode string   `json:"errorCode"`
+	Fields    []string `json:"fields,omitempty"`
+	// ExtendedErrorDetails holds the additional details reported for some errors, such as the rule that rejected a
+	// duplicate record.
+	ExtendedErrorDetails []map[string]interface{} `json:"extendedErrorDetails,omitempty"`
+}
+
+// Error formats the first reported error.
+func (err *APIError) Error() string {
+	if len(err.Errors) == 0 {
+		return fmt.Sprintf(logPrefix+" Error. http code: %v Error Message:  %v Error Code: %v", err.StatusCode, ErrFailure, "")
+	}
+	return fmt.Sprintf(logPrefix+" Error. http code: %v Error Message:  %v Error Code: %v", err.StatusCode, err.Errors[0].Message, err.Errors[0].ErrorCode)
+}
+
+// Is reports whether any of the errors matches target, one of the sentinel errors of this package. An error which
+// could not be parsed matches ErrFailure.
+func (err *APIError) Is(target error) bool {
+	if target == ErrFailure {
+		return len(err.Errors) == 0
+	}
+	if target == ErrNotFound && err.StatusCode == 404 {
+		return true
+	}
+	for _, code := range sentinelErrorCodes[target] {
+		if err.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrorCode checks if any of the errors has the error code. SOAP fault codes are matched without their namespace
+// prefix, e.g. "INVALID_LOGIN" matches "sf:INVALID_LOGIN".
+func (err *APIError) HasErrorCode(code string) bool {
+	for _, e := range err.Errors {
+		if trimErrorCode(e.ErrorCode) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// summary lists the error codes and messages, to be logged instead of the response body which may hold record data.
+func (err *APIError) summary() string {
+	if len(err.Errors) == 0 {
+		return fmt.Sprintf("http code %d", err.StatusCode)
+	}
+	entries := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		entries[i] = e.ErrorCode + ": " + e.Message
+	}
+	return strings.Join(entries, "; ")
+}
+
+// trimErrorCode removes the namespace prefix of SOAP fault codes, e.g. "sf:INVALID_LOGIN" gives "INVALID_LOGIN".
+func trimErrorCode(code string) string {
+	if i := strings.LastIndex(code, ":"); i != -1 {
+		return code[i+1:]
+	}
+	return code
+}
+
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
 }
 
 type xmlError struct {
-	Message string `xml:"Body>Fault>faultstring"`
+	Message   string `xml:"Body>Fault>faultstring"`
 	ErrorCode string `xml:"Body>Fault>faultcode"`
 }
 
-//Need to get information out of this package.
+// ParseSalesforceError parses the response data of a failed request into an *APIError.
 func ParseSalesforceError(statusCode int, responseBody []byte) (err error) {
-	jsonError := jsonError{}
+	apiError := &APIError{StatusCode: statusCode, Body: responseBody}
+
+	//Try the json array of the REST API
+	if json.Unmarshal(responseBody, &apiError.Errors) == nil {
+		return apiError
+	}
+	apiError.Errors = nil
+
+	//Unable to parse json array. Try the OAuth error object
+	oauthError := oauthError{}
+	if json.Unmarshal(responseBody, &oauthError) == nil && oauthError.Error != "" {
+		apiError.Errors = []APIErrorEntry{{Message: oauthError.Description, ErrorCode: oauthError.Error}}
+		return apiError
+	}
+
+	//Unable to parse json. Try xml
 	xmlError := xmlError{}
-	err = json.Unmarshal(responseBody, &jsonError)
-	if err != nil {
-		//Unable to parse json. Try xml
-		err = xml.Unmarshal(responseBody, &xmlError)
-		if err != nil {
-			//Unable to parse json or XML
-			log.Println("ERROR UNMARSHALLING: ", err)
-			return ErrFailure
-		}
-		//successfully parsed XML:
-		message := fmt.Sprintf(logPrefix+" Error. http code: %v Error Message:  %v Error Code: %v", statusCode, xmlError.Message, xmlError.ErrorCode)
-		err = errors.New(message)
-		return err
-	} else {
-		//Successfully parsed json error:
-		message := fmt.Sprintf(logPrefix+" Error. http code: %v Error Message:  %v Error Code: %v", statusCode, jsonError[0].Message, jsonError[0].ErrorCode)
-		err = errors.New(message)
-		return err
+	if xml.Unmarshal(responseBody, &xmlError) == nil && (xmlError.ErrorCode != "" || xmlError.Message != "") {
+		apiError.Errors = []APIErrorEntry{{Message: xmlError.Message, ErrorCode: xmlError.ErrorCode}}
 	}
+	return apiError
 }
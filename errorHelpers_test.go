@@ -0,0 +1,56 @@
+package simpleforce
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseSalesforceError_json(t *testing.T) {
+	body := []byte(`[
+		{"message":"duplicate value found: Email__c","errorCode":"DUPLICATE_VALUE","fields":["Email__c"]},
+		{"message":"Required fields are missing: [LastName]","errorCode":"REQUIRED_FIELD_MISSING","fields":["LastName"],
+		 "extendedErrorDetails":[{"extendedErrorCode":"FIELD_MISSING"}]}
+	]`)
+	err := ParseSalesforceError(400, body)
+
+	var apiError *APIError
+	if !errors.As(errors.Wrap(err, "create failed"), &apiError) {
+		t.Fatal(err)
+	}
+	if apiError.StatusCode != 400 || len(apiError.Errors) != 2 || string(apiError.Body) != string(body) {
+		t.Fail()
+	}
+	if apiError.Errors[1].Fields[0] != "LastName" || apiError.Errors[1].ExtendedErrorDetails[0]["extendedErrorCode"] != "FIELD_MISSING" {
+		t.Fail()
+	}
+	if err.Error() != "[simpleforce] Error. http code: 400 Error Message:  duplicate value found: Email__c Error Code: DUPLICATE_VALUE" {
+		t.Error(err)
+	}
+	if !errors.Is(err, ErrDuplicateValue) || !errors.Is(err, ErrRequiredFieldMissing) || errors.Is(err, ErrInvalidSession) {
+		t.Fail()
+	}
+}
+
+func TestParseSalesforceError_formats(t *testing.T) {
+	oauth := ParseSalesforceError(400, []byte(`{"error":"invalid_grant","error_description":"authentication failure"}`))
+	if !errors.Is(oauth, ErrAuthentication) || errors.Is(oauth, ErrFailure) {
+		t.Error(oauth)
+	}
+
+	soap := ParseSalesforceError(500, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:sf="urn:fault.partner.soap.sforce.com">
+			<soapenv:Body><soapenv:Fault>
+				<faultcode>sf:INVALID_LOGIN</faultcode>
+				<faultstring>INVALID_LOGIN: Invalid username, password, security token; or user locked out.</faultstring>
+			</soapenv:Fault></soapenv:Body>
+		</soapenv:Envelope>`))
+	if !errors.Is(soap, ErrAuthentication) || !soap.(*APIError).HasErrorCode("INVALID_LOGIN") {
+		t.Error(soap)
+	}
+
+	unknown := ParseSalesforceError(404, []byte("<html>Not Found</html>"))
+	if !errors.Is(unknown, ErrFailure) || !errors.Is(unknown, ErrNotFound) || len(unknown.(*APIError).Body) == 0 {
+		t.Error(unknown)
+	}
+}
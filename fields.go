@@ -0,0 +1,147 @@
+package simpleforce
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrFieldMissing is returned by the typed field accessors when the SObject doesn't have the field, e.g. because it
+	// wasn't queried.
+	ErrFieldMissing = errors.New("field missing")
+	// ErrFieldNull is returned by the typed field accessors when the field is null.
+	ErrFieldNull = errors.New("field is null")
+)
+
+// Has tells whether the SObject has the field, even if it is null.
+func (obj *SObject) Has(key string) bool {
+	_, ok := (*obj)[key]
+	return ok
+}
+
+// IsNull tells whether the SObject has the field and it is null. A missing field isn't null, see Has.
+func (obj *SObject) IsNull(key string) bool {
+	value, ok := (*obj)[key]
+	return ok && value == nil
+}
+
+// IntField accesses a field in the SObject as an integer. An error is returned if the field is missing, null, or isn't
+// an integer.
+func (obj *SObject) IntField(key string) (int64, error) {
+	value, err := obj.field(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := toInt(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "field %s", key)
+	}
+	return i, nil
+}
+
+// FloatField accesses a field in the SObject as a float. An error is returned if the field is missing, null, or isn't a
+// number.
+func (obj *SObject) FloatField(key string) (float64, error) {
+	value, err := obj.field(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := toFloat(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "field %s", key)
+	}
+	return f, nil
+}
+
+// DecimalField accesses a field in the SObject as an exact decimal, e.g. for currency and percent fields, so that
+// values such as 0.1 don't suffer from the rounding of float arithmetic. Numbers decoded as float64 give back the
+// decimal returned by salesforce up to 15 significant digits; enable Client.SetUseNumber to read up to 18 digits. An
+// error is returned if the field is missing, null, or isn't a number.
+func (obj *SObject) DecimalField(key string) (*big.Rat, error) {
+	value, err := obj.field(key)
+	if err != nil {
+		return nil, err
+	}
+	d, err := toDecimal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "field %s", key)
+	}
+	return d, nil
+}
+
+// BoolField accesses a field in the SObject as a boolean. An error is returned if the field is missing, null, or isn't a
+// boolean.
+func (obj *SObject) BoolField(key string) (bool, error) {
+	value, err := obj.field(key)
+	if err != nil {
+		return false, err
+	}
+	switch value := value.(type) {
+	case bool:
+		return value, nil
+	case string:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, errors.Wrapf(err, "field %s", key)
+		}
+		return b, nil
+	}
+	return false, errors.Errorf("field %s: unable to decode %T into a boolean", key, value)
+}
+
+// DateField accesses a date field in the SObject, e.g. "2006-01-02", as a time at midnight UTC. An error is returned if
+// the field is missing, null, or isn't a date.
+func (obj *SObject) DateField(key string) (time.Time, error) {
+	s, err := obj.timeString(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "field %s", key)
+	}
+	return t, nil
+}
+
+// TimeField accesses a datetime field in the SObject, e.g. "2006-01-02T15:04:05.000+0000", as a time. Dates and time
+// fields, e.g. "15:04:05.000Z", are parsed as well. An error is returned if the field is missing, null, or isn't a
+// datetime.
+func (obj *SObject) TimeField(key string) (time.Time, error) {
+	s, err := obj.timeString(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "field %s", key)
+	}
+	return t, nil
+}
+
+// field returns the value of a field, or an error if it is missing or null.
+func (obj *SObject) field(key string) (interface{}, error) {
+	value, ok := (*obj)[key]
+	switch {
+	case !ok:
+		return nil, errors.Wrapf(ErrFieldMissing, "field %s", key)
+	case value == nil:
+		return nil, errors.Wrapf(ErrFieldNull, "field %s", key)
+	}
+	return value, nil
+}
+
+// timeString returns the value of a date, datetime or time field.
+func (obj *SObject) timeString(key string) (string, error) {
+	value, err := obj.field(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", errors.Errorf("field %s: unable to decode %T into a time", key, value)
+	}
+	return s, nil
+}
@@ -0,0 +1,65 @@
+package simpleforce
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestSObject_typedFields(t *testing.T) {
+	var obj SObject
+	err := json.Unmarshal([]byte(`{
+		"NumberOfEmployees": 250,
+		"AnnualRevenue": 1234567.1,
+		"Discount__c": 0.1,
+		"IsDeleted": false,
+		"CloseDate": "2021-03-04",
+		"LastModifiedDate": "2021-03-04T05:06:07.000+0100",
+		"Description": null,
+		"Name": "Acme"
+	}`), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := obj.IntField("NumberOfEmployees"); err != nil || n != 250 {
+		t.Error(n, err)
+	}
+	if _, err := obj.IntField("AnnualRevenue"); err == nil {
+		t.Error("fractional number read as an integer")
+	}
+	if f, err := obj.FloatField("AnnualRevenue"); err != nil || f != 1234567.1 {
+		t.Error(f, err)
+	}
+	if d, err := obj.DecimalField("Discount__c"); err != nil || d.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Error(d, err)
+	}
+	if b, err := obj.BoolField("IsDeleted"); err != nil || b {
+		t.Error(b, err)
+	}
+	if d, err := obj.DateField("CloseDate"); err != nil || !d.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Error(d, err)
+	}
+	if d, err := obj.TimeField("LastModifiedDate"); err != nil || !d.Equal(time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)) {
+		t.Error(d, err)
+	}
+	if _, err := obj.DateField("LastModifiedDate"); err == nil {
+		t.Error("datetime read as a date")
+	}
+
+	if !obj.Has("Description") || !obj.IsNull("Description") || obj.Has("Phone") || obj.IsNull("Phone") || obj.IsNull("Name") {
+		t.Fail()
+	}
+	if _, err := obj.FloatField("Description"); !errors.Is(err, ErrFieldNull) {
+		t.Error(err)
+	}
+	if _, err := obj.TimeField("Phone"); !errors.Is(err, ErrFieldMissing) {
+		t.Error(err)
+	}
+	if _, err := obj.BoolField("Name"); err == nil {
+		t.Error("string read as a boolean")
+	}
+}
@@ -1,18 +1,21 @@
 package simpleforce
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"html"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"bytes"
+
+	"github.com/pkg/errors"
 )
 
 const (
@@ -26,18 +29,52 @@ const (
 // Client is the main instance to access salesforce.
 type Client struct {
 	sessionID string
-	user      struct {
-		id       string
-		name     string
-		fullName string
-		email    string
+	user      Identity
+	// identityURL is the OAuth identity URL of the user, and identityLoaded is set once user has been populated
+	// either from the SOAP login response or by CurrentUser.
+	identityURL    string
+	identityLoaded bool
+	oauth struct {
+		consumerKey    string
+		consumerSecret string
+		refreshToken   string
 	}
 	clientID      string
 	apiVersion    string
 	baseURL       string
 	instanceURL   string
 	useToolingAPI bool
+	useNumber     bool
 	httpClient    *http.Client
+	tokenStore    TokenStore
+	callOptions   CallOptions
+	retryPolicy   *RetryPolicy
+	logger        Logger
+	limiter       *rateLimiter
+	middlewares   []Middleware
+	// handler sends the calls through the middlewares.
+	handler Handler
+	// inFlight holds a token for each request in flight when the number of concurrent requests is limited.
+	inFlight chan struct{}
+	// oauthSession is set if the session was acquired through OAuth, in which case the tokens are revoked on logout.
+	oauthSession bool
+
+	// mu guards the session (sessionID, instanceURL, user and login) which may be renewed by re-authentication while
+	// other goroutines are using the client, and autoReauth.
+	mu sync.RWMutex
+	// login repeats the last successful login, and is used to re-authenticate when the session expires.
+	login      func(ctx context.Context) error
+	autoReauth bool
+	// reauthMu serializes re-authentication so that only one login is run when many requests hit an expired session.
+	reauthMu sync.Mutex
+
+	// usageMu guards the API usage reported by the responses, and the threshold and limit set on it.
+	usageMu               sync.Mutex
+	usage                 APIUsage
+	usageThreshold        float64
+	usageThresholdReached bool
+	usageCallback         func(usage APIUsage)
+	usageLimit            float64
 }
 
 // QueryResult holds the response data from an SOQL query.
@@ -50,22 +87,78 @@ type QueryResult struct {
 
 // Expose sid to save in admin settings
 func (client *Client) GetSid() (sid string) {
-        return client.sessionID
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.sessionID
 }
 
 //Expose Loc to save in admin settings
 func (client *Client) GetLoc() (loc string) {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
 	return client.instanceURL
 }
 
 // Set SID and Loc as a means to log in without LoginPassword
 func (client *Client) SetSidLoc(sid string, loc string) {
-        client.sessionID = sid
-        client.instanceURL = loc
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sessionID = sid
+	client.instanceURL = loc
+}
+
+// SetAutoReauth enables or disables automatic re-authentication. When enabled, a request failing with
+// INVALID_SESSION_ID runs the last successful login (password, JWT or refresh token) once and is then retried.
+func (client *Client) SetAutoReauth(enabled bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.autoReauth = enabled
+}
+
+// SetUseNumber enables or disables decoding the numbers of the records returned by Query and Get as json.Number rather
+// than float64, so that numbers with up to 18 digits, as allowed by salesforce, aren't rounded. DecimalField, IntField
+// and Decode read them exactly.
+func (client *Client) SetUseNumber(enabled bool) {
+	client.useNumber = enabled
+}
+
+// autoReauthEnabled returns if automatic re-authentication is enabled.
+func (client *Client) autoReauthEnabled() bool {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.autoReauth
 }
 
 // Query runs an SOQL query. q could either be the SOQL string or the nextRecordsURL.
 func (client *Client) Query(q string) (*QueryResult, error) {
+	return client.QueryContext(context.Background(), q)
+}
+
+// QueryContext runs an SOQL query with the context. q could either be the SOQL string or the nextRecordsURL.
+func (client *Client) QueryContext(ctx context.Context, q string) (*QueryResult, error) {
+	resource := "query"
+	if client.useToolingAPI {
+		resource = "tooling/query"
+	}
+	return client.query(withOperation(ctx, "Query", ResourceQuery, ""), resource, q)
+}
+
+// QueryAll runs an SOQL query including the deleted records in the recycle bin (IsDeleted = true) and the archived
+// activities. q could either be the SOQL string or the nextRecordsURL.
+// Ref: https://developer.salesforce.com/docs/atlas.en-us.api_rest.meta/api_rest/resources_queryall.htm
+func (client *Client) QueryAll(q string) (*QueryResult, error) {
+	return client.QueryAllContext(context.Background(), q)
+}
+
+// QueryAllContext runs an SOQL query including the deleted and archived records with the context. q could either be
+// the SOQL string or the nextRecordsURL.
+func (client *Client) QueryAllContext(ctx context.Context, q string) (*QueryResult, error) {
+	return client.query(withOperation(ctx, "QueryAll", ResourceQuery, ""), "queryAll", q)
+}
+
+// query runs an SOQL query against the resource, e.g. "query" or "tooling/query", or fetches the next records of a
+// query.
+func (client *Client) query(ctx context.Context, resource, q string) (*QueryResult, error) {
 	if !client.isLoggedIn() {
 		return nil, ErrAuthentication
 	}
@@ -73,25 +166,20 @@ func (client *Client) Query(q string) (*QueryResult, error) {
 	var u string
 	if strings.HasPrefix(q, "/services/data") {
 		// q is nextRecordsURL.
-		u = fmt.Sprintf("%s%s", client.instanceURL, q)
+		u = fmt.Sprintf("%s%s", client.GetLoc(), q)
 	} else {
 		// q is SOQL.
-		formatString := "%s/services/data/v%s/query?q=%s"
-		baseURL := client.instanceURL
-		if client.useToolingAPI {
-			formatString = strings.Replace(formatString, "query", "tooling/query", -1)
-		}
-		u = fmt.Sprintf(formatString, baseURL, client.apiVersion, url.PathEscape(q))
+		u = fmt.Sprintf("%s/services/data/v%s/%s?q=%s", client.GetLoc(), client.apiVersion, resource, url.PathEscape(q))
 	}
 
-	data, err := client.httpRequest("GET", u, nil)
+	data, err := client.httpRequestContext(ctx, "GET", u, nil)
 	if err != nil {
-		log.Println(logPrefix, "HTTP GET request failed:", u)
+		client.log(LogLevelDebug, "query failed", "url", u, "error", err)
 		return nil, err
 	}
 
 	var result QueryResult
-	err = json.Unmarshal(data, &result)
+	err = client.unmarshalRecords(data, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -116,13 +204,27 @@ func (client *Client) SObject(typeName ...string) *SObject {
 
 // isLoggedIn returns if the login to salesforce is successful.
 func (client *Client) isLoggedIn() bool {
-	return client.sessionID != ""
+	return client.GetSid() != ""
 }
 
 // LoginPassword signs into salesforce using password. token is optional if trusted IP is configured.
 // Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api_rest.meta/api_rest/intro_understanding_username_password_oauth_flow.htm
 // Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api.meta/api/sforce_api_calls_login.htm
 func (client *Client) LoginPassword(username, password, token string) error {
+	return client.LoginPasswordContext(context.Background(), username, password, token)
+}
+
+// LoginPasswordContext signs into salesforce using password with the context.
+func (client *Client) LoginPasswordContext(ctx context.Context, username, password, token string) error {
+	return client.authenticate(ctx, func(ctx context.Context) error {
+		return client.loginPassword(ctx, username, password, token)
+	})
+}
+
+// loginPassword runs the SOAP login call, without consulting the token store.
+func (client *Client) loginPassword(ctx context.Context, username, password, token string) error {
+	ctx = withOperation(ctx, "LoginPassword", ResourceLogin, "")
+
 	// Use the SOAP interface to acquire session ID with username, password, and token.
 	// Do not use REST interface here as REST interface seems to have strong checking against client_id, while the SOAP
 	// interface allows a non-exist placeholder client_id to be used.
@@ -148,36 +250,27 @@ func (client *Client) LoginPassword(username, password, token string) error {
 	soapBody = fmt.Sprintf(soapBody, client.clientID, username, html.EscapeString(password), token)
 
 	url := fmt.Sprintf("%s/services/Soap/u/%s", client.baseURL, client.apiVersion)
-	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(soapBody))
+	// Logging in again has no side effect, so the login can be retried.
+	req, err := newRequest(withIdempotent(ctx, true), http.MethodPost, url, []byte(soapBody))
 	if err != nil {
-		log.Println(logPrefix, "error occurred creating request,", err)
+		client.log(LogLevelError, "error occurred creating login request", "error", err)
 		return err
 	}
 	req.Header.Add("Content-Type", "text/xml")
 	req.Header.Add("charset", "UTF-8")
 	req.Header.Add("SOAPAction", "login")
 
-	resp, err := client.httpClient.Do(req)
+	resp, err := client.send(req)
 	if err != nil {
-		log.Println(logPrefix, "error occurred submitting request,", err)
+		client.log(LogLevelError, "error occurred submitting login request", "error", err)
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Println(logPrefix, "request failed,", resp.StatusCode)
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(resp.Body)
-		newStr := buf.String()
-		log.Println(logPrefix, "Failed resp.body: ", newStr)
-		theError := ParseSalesforceError(resp.StatusCode, buf.Bytes())
-		return theError
-	}
-
 	respData, err := ioutil.ReadAll(resp.Body)
 
 	if err != nil {
-		log.Println(logPrefix, "error occurred reading response data,", err)
+		client.log(LogLevelError, "error occurred reading login response", "error", err)
 	}
 
 	var loginResponse struct {
@@ -185,62 +278,286 @@ func (client *Client) LoginPassword(username, password, token string) error {
 		ServerURL    string   `xml:"Body>loginResponse>result>serverUrl"`
 		SessionID    string   `xml:"Body>loginResponse>result>sessionId"`
 		UserID       string   `xml:"Body>loginResponse>result>userId"`
+		Sandbox      bool     `xml:"Body>loginResponse>result>sandbox"`
+		OrgID        string   `xml:"Body>loginResponse>result>userInfo>organizationId"`
+		ProfileID    string   `xml:"Body>loginResponse>result>userInfo>profileId"`
 		UserEmail    string   `xml:"Body>loginResponse>result>userInfo>userEmail"`
 		UserFullName string   `xml:"Body>loginResponse>result>userInfo>userFullName"`
 		UserName     string   `xml:"Body>loginResponse>result>userInfo>userName"`
+		UserLocale   string   `xml:"Body>loginResponse>result>userInfo>userLocale"`
+		UserTimeZone string   `xml:"Body>loginResponse>result>userInfo>userTimeZone"`
+		UserType     string   `xml:"Body>loginResponse>result>userInfo>userType"`
 	}
 
 	err = xml.Unmarshal(respData, &loginResponse)
 	if err != nil {
-		log.Println(logPrefix, "error occurred parsing login response,", err)
+		client.log(LogLevelError, "error occurred parsing login response", "error", err)
 		return err
 	}
 
 	// Now we should all be good and the sessionID can be used to talk to salesforce further.
+	client.mu.Lock()
 	client.sessionID = loginResponse.SessionID
 	client.instanceURL = parseHost(loginResponse.ServerURL)
-	client.user.id = loginResponse.UserID
-	client.user.name = loginResponse.UserName
-	client.user.email = loginResponse.UserEmail
-	client.user.fullName = loginResponse.UserFullName
+	client.user = Identity{
+		UserID:    loginResponse.UserID,
+		OrgID:     loginResponse.OrgID,
+		Username:  loginResponse.UserName,
+		FullName:  loginResponse.UserFullName,
+		Email:     loginResponse.UserEmail,
+		ProfileID: loginResponse.ProfileID,
+		Locale:    loginResponse.UserLocale,
+		TimeZone:  loginResponse.UserTimeZone,
+		UserType:  loginResponse.UserType,
+		IsSandbox: loginResponse.Sandbox,
+	}
+	client.identityURL = ""
+	client.identityLoaded = true
+	client.oauthSession = false
+	client.mu.Unlock()
+
+	client.log(LogLevelInfo, "user authenticated", "username", loginResponse.UserName)
+	return nil
+}
 
-	log.Println(logPrefix, "User", client.user.name, "authenticated.")
+// Logout ends the session. Sessions acquired through OAuth are revoked, including the refresh token if any; other
+// sessions are ended with the SOAP logout call. The client state is cleared even if the call fails, in which case the
+// error is returned.
+// Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api.meta/api/sforce_api_calls_logout.htm
+func (client *Client) Logout() error {
+	return client.LogoutContext(context.Background())
+}
+
+// LogoutContext ends the session with the context.
+func (client *Client) LogoutContext(ctx context.Context) error {
+	if !client.isLoggedIn() {
+		return ErrAuthentication
+	}
+
+	client.mu.RLock()
+	sessionID, instanceURL := client.sessionID, client.instanceURL
+	refreshToken, oauthSession := client.oauth.refreshToken, client.oauthSession
+	client.mu.RUnlock()
+
+	var err error
+	switch {
+	case refreshToken != "":
+		// Revoking the refresh token revokes the access tokens issued with it as well.
+		err = client.revokeToken(ctx, refreshToken)
+	case oauthSession:
+		err = client.revokeToken(ctx, sessionID)
+	default:
+		err = client.logoutSOAP(ctx, instanceURL, sessionID)
+	}
+
+	client.mu.Lock()
+	client.sessionID = ""
+	client.instanceURL = ""
+	client.oauth.refreshToken = ""
+	client.oauthSession = false
+	client.login = nil
+	client.user = Identity{}
+	client.identityURL = ""
+	client.identityLoaded = false
+	client.mu.Unlock()
+
+	if client.tokenStore != nil {
+		if storeErr := client.tokenStore.Invalidate(sessionID); storeErr != nil {
+			client.log(LogLevelWarn, "error occurred invalidating stored session", "error", storeErr)
+		}
+	}
+
+	if err != nil {
+		client.log(LogLevelWarn, "logout failed", "error", err)
+		return err
+	}
+	client.log(LogLevelInfo, "logged out")
 	return nil
 }
 
-// httpRequest executes an HTTP request to the salesforce server and returns the response data in byte buffer.
-func (client *Client) httpRequest(method, url string, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequest(method, url, body)
+// logoutSOAP ends the session with the SOAP logout call.
+func (client *Client) logoutSOAP(ctx context.Context, instanceURL, sessionID string) error {
+	ctx = withOperation(ctx, "Logout", ResourceLogin, "")
+
+	soapBody := `<?xml version="1.0" encoding="utf-8" ?>
+        <env:Envelope
+                xmlns:xsd="http://www.w3.org/2001/XMLSchema"
+                xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+                xmlns:env="http://schemas.xmlsoap.org/soap/envelope/"
+                xmlns:urn="urn:partner.soap.sforce.com">
+            <env:Header>
+                <urn:SessionHeader>
+                    <urn:sessionId>%s</urn:sessionId>
+                </urn:SessionHeader>
+            </env:Header>
+            <env:Body>
+                <urn:logout/>
+            </env:Body>
+        </env:Envelope>`
+	soapBody = fmt.Sprintf(soapBody, html.EscapeString(sessionID))
+
+	url := fmt.Sprintf("%s/services/Soap/u/%s", instanceURL, client.apiVersion)
+	req, err := newRequest(ctx, http.MethodPost, url, []byte(soapBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "text/xml")
+	req.Header.Add("charset", "UTF-8")
+	req.Header.Add("SOAPAction", "logout")
+
+	resp, err := client.send(req)
 	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// httpRequestContext executes an HTTP request to the salesforce server with the context and returns the response data
+// in byte buffer.
+func (client *Client) httpRequestContext(ctx context.Context, method, url string, body io.Reader) ([]byte, error) {
+	_, data, err := client.httpRequestWithStatus(ctx, method, url, body)
+	return data, err
+}
+
+// httpRequestWithStatus executes an HTTP request like httpRequestContext, and also returns the status of the response.
+func (client *Client) httpRequestWithStatus(ctx context.Context, method, url string, body io.Reader) (int, []byte, error) {
+	// Keep the request body so that it can be sent again, e.g. after re-authentication.
+	var reqData []byte
+	if body != nil {
+		var err error
+		reqData, err = ioutil.ReadAll(body)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	resp, err := client.sendAuthenticated(ctx, method, url, reqData)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
+
+// sendAuthenticated sends a request with the session ID of the client, unless the API usage limit has been reached. If
+// automatic re-authentication is enabled and the session has expired, the request is sent again once after login.
+// The caller must close the response body.
+func (client *Client) sendAuthenticated(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if err := client.checkAPIUsage(ctx); err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", client.sessionID))
+	sessionID := client.GetSid()
+	resp, err := client.sendWithSession(ctx, method, url, body, sessionID)
+	if err != nil && client.autoReauthEnabled() && errors.Is(err, ErrInvalidSession) {
+		client.log(LogLevelInfo, "session expired, re-authenticating")
+		if reauthErr := client.reauthenticate(ctx, sessionID); reauthErr != nil {
+			client.log(LogLevelError, "re-authentication failed", "error", reauthErr)
+			return nil, err
+		}
+		resp, err = client.sendWithSession(ctx, method, url, body, client.GetSid())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// sendWithSession sends a REST request using sessionID as the bearer token, with the call options of the client.
+func (client *Client) sendWithSession(ctx context.Context, method, url string, body []byte, sessionID string) (*http.Response, error) {
+	req, err := newRequest(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", sessionID))
 	req.Header.Add("Content-Type", "application/json")
+	client.applyCallOptions(req)
+
+	return client.send(req)
+}
 
+// sendOnce sends the request to the salesforce server once. A response with a 2xx status is returned as is and the
+// caller must close its body; otherwise the response data is read and returned along with the error parsed from it.
+func (client *Client) sendOnce(req *http.Request) (*http.Response, []byte, error) {
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		client.log(LogLevelDebug, "request failed", "method", req.Method, "url", req.URL.String(), "error", err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	client.recordAPIUsage(resp)
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		log.Println(logPrefix, "request failed,", resp.StatusCode)
+		defer resp.Body.Close()
 		buf := new(bytes.Buffer)
 		buf.ReadFrom(resp.Body)
-		newStr := buf.String()
 		theError := ParseSalesforceError(resp.StatusCode, buf.Bytes())
-		log.Println(logPrefix, "Failed resp.body: ", newStr)
-		return nil, theError
+		// The body isn't logged as it may hold record data.
+		summary := ""
+		if apiError, ok := theError.(*APIError); ok {
+			summary = apiError.summary()
+		}
+		client.log(LogLevelDebug, "request failed", "method", req.Method, "url", req.URL.String(),
+			"status", resp.StatusCode, "errors", summary)
+		return resp, buf.Bytes(), theError
 	}
 
-	return ioutil.ReadAll(resp.Body)
+	return resp, nil, nil
+}
+
+// unmarshalRecords decodes records from JSON. The numbers are decoded as float64, or as json.Number if enabled with
+// SetUseNumber.
+func (client *Client) unmarshalRecords(data []byte, v interface{}) error {
+	if !client.useNumber {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// newRequest creates a request with the context. The body is kept by the request so that it can be sent again.
+func newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, url, reqBody)
+}
+
+// reauthenticate runs the last successful login again, unless another goroutine has already renewed the session
+// since staleSessionID was used.
+func (client *Client) reauthenticate(ctx context.Context, staleSessionID string) error {
+	client.reauthMu.Lock()
+	defer client.reauthMu.Unlock()
+
+	client.mu.RLock()
+	sessionID, login := client.sessionID, client.login
+	client.mu.RUnlock()
+
+	if sessionID != staleSessionID {
+		// Session has been renewed while waiting for the lock.
+		return nil
+	}
+	if login == nil {
+		return ErrAuthentication
+	}
+	if client.tokenStore != nil {
+		// Drop the expired session so that login either picks up a session renewed by another process or logs in.
+		if err := client.tokenStore.Invalidate(staleSessionID); err != nil {
+			client.log(LogLevelWarn, "error occurred invalidating stored session", "error", err)
+		}
+	}
+	return login(ctx)
 }
 
 // makeURL generates a REST API URL based on baseURL, APIVersion of the client.
 func (client *Client) makeURL(req string) string {
-	client.apiVersion = strings.Replace(client.apiVersion, "v", "", -1)
-	retURL := fmt.Sprintf("%s/services/data/v%s/%s", client.instanceURL, client.apiVersion, req)
+	apiVersion := strings.Replace(client.apiVersion, "v", "", -1)
+	retURL := fmt.Sprintf("%s/services/data/v%s/%s", client.GetLoc(), apiVersion, req)
 	return retURL
 }
 
@@ -266,21 +583,21 @@ func (client *Client) SetHttpClient(c *http.Client) {
 
 // DownloadFile downloads a file based on the REST API path given. Saves to filePath.
 func (client *Client) DownloadFile(contentVersionID string, filepath string) error {
+	return client.DownloadFileContext(context.Background(), contentVersionID, filepath)
+}
 
-	apiPath := fmt.Sprintf("/services/data/v%s/sobjects/ContentVersion/%s/VersionData", client.apiVersion, contentVersionID)
+// DownloadFileContext downloads a file with the context. Saves to filePath.
+func (client *Client) DownloadFileContext(ctx context.Context, contentVersionID string, filepath string) error {
+	ctx = withOperation(ctx, "DownloadFile", ResourceFile, "ContentVersion")
 
-	baseURL := strings.TrimRight(client.baseURL, "/")
-	url := fmt.Sprintf("%s%s", baseURL, apiPath)
+	if !client.isLoggedIn() {
+		return ErrAuthentication
+	}
+
+	url := client.makeURL("sobjects/ContentVersion/" + contentVersionID + "/VersionData")
 
 	// Get the data
-	httpClient := client.httpClient
-	req, err := http.NewRequest("GET", url, nil)
-	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", "Bearer "+client.sessionID)
-
-	// resp, err := http.Get(url)
-	resp, err := httpClient.Do(req)
+	resp, err := client.sendAuthenticated(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
@@ -308,29 +625,24 @@ func parseHost(input string) string {
 
 //Get the List of all available objects and their metadata for your organization's data
 func (client *Client) DescribeGlobal() (*SObjectMeta, error) {
-	apiPath := fmt.Sprintf("/services/data/v%s/sobjects", client.apiVersion)
-	baseURL := strings.TrimRight(client.baseURL, "/")
-	url := fmt.Sprintf("%s%s", baseURL, apiPath) // Get the objects
-	httpClient := client.httpClient
-	req, err := http.NewRequest("GET", url, nil)
-	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", "Bearer "+client.sessionID)
-	// resp, err := http.Get(url)
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return client.DescribeGlobalContext(context.Background())
+}
 
-	var meta SObjectMeta
+// DescribeGlobalContext gets the list of all available objects with the context.
+func (client *Client) DescribeGlobalContext(ctx context.Context) (*SObjectMeta, error) {
+	ctx = withOperation(ctx, "DescribeGlobal", ResourceDescribe, "")
 
-	respData, err := ioutil.ReadAll(resp.Body)
-	log.Println(logPrefix, fmt.Sprintf("status code %d", resp.StatusCode))
+	if !client.isLoggedIn() {
+		return nil, ErrAuthentication
+	}
+
+	url := client.makeURL("sobjects")
+	respData, err := client.httpRequestContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		log.Println(logPrefix, "error while reading all body")
+		return nil, err
 	}
 
+	var meta SObjectMeta
 	err = json.Unmarshal(respData, &meta)
 	if err != nil {
 		return nil, err
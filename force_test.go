@@ -1,11 +1,21 @@
 package simpleforce
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math/big"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/simpleforce/simpleforce/cassette"
 )
 
 var (
@@ -65,6 +75,21 @@ func requireClient(t *testing.T, skippable bool) *Client {
 	return client
 }
 
+// requireLiveClient logs in to the org set with the SF_* environment variables, skipping the test if they aren't set.
+func requireLiveClient(t *testing.T) *Client {
+	if sfUser == "" || sfPass == "" {
+		t.Skip("SF_USER and SF_PASS are not set")
+	}
+	httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	err := client.LoginPassword(sfUser, sfPass, sfToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
 func TestClient_LoginPassword_success(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -158,13 +183,298 @@ func TestClient_Query(t *testing.T) {
 		httpmock.NewStringResponder(200, `{"TotalSize": 0, "Done": true, "NextRecordsURL": "NextRecordsURL", "records": []}`))
 
 	q := "SELECT Id,LastModifiedById,LastModifiedDate,ParentId,CommentBody FROM CaseComment"
-	result, err := client.Query(q)
+	_, err := client.Query(q)
 	if err != nil {
 		log.Println(logPrefix, "query failed,", err)
 		t.FailNow()
 	}
 }
 
+// registerCountingLoginMock registers a login responder handing out a new session ID ("session1", "session2", ...)
+// on every call, and returns the counter of logins.
+func registerCountingLoginMock(c *Client) *int32 {
+	var logins int32
+	mockURL := "https://login.salesforce.com//services/Soap/u/" + c.apiVersion
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&logins, 1)
+		return httpmock.NewStringResponse(200, fmt.Sprintf(`<?xml version="1.0" encoding="utf-8" ?>
+			<env:Envelope>
+				<env:Body>
+					<env:loginResponse>
+						<env:result>
+							<env:serverUrl>https://na0-api.salesforce.com/services/Soap/c/2.5</env:serverUrl>
+							<env:sessionId>session%d</env:sessionId>
+							<env:userId>userId</env:userId>
+						</env:result>
+					</env:loginResponse>
+				</env:Body>
+			</env:Envelope>`, n)), nil
+	})
+	return &logins
+}
+
+// registerSessionCheckingQueryMock registers a query responder which only accepts the given session ID.
+func registerSessionCheckingQueryMock(c *Client, validSessionID string) {
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + c.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") != "Bearer "+validSessionID {
+			return httpmock.NewStringResponse(401, `[{"message":"Session expired or invalid","errorCode":"INVALID_SESSION_ID"}]`), nil
+		}
+		return httpmock.NewStringResponse(200, `{"totalSize": 0, "done": true, "records": []}`), nil
+	})
+}
+
+func TestClient_AutoReauth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	logins := registerCountingLoginMock(client)
+	registerSessionCheckingQueryMock(client, "session2")
+
+	if err := client.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabled by default: the error is returned to the caller.
+	if _, err := client.Query("SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+
+	client.SetAutoReauth(true)
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "session2" || atomic.LoadInt32(logins) != 2 {
+		t.Fail()
+	}
+}
+
+func TestClient_AutoReauth_concurrent(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.SetAutoReauth(true)
+	logins := registerCountingLoginMock(client)
+	registerSessionCheckingQueryMock(client, "session2")
+
+	if err := client.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var failures int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+				atomic.AddInt32(&failures, 1)
+			}
+		}()
+	}
+	// The setting may change while requests are running.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.SetAutoReauth(true)
+	}()
+	wg.Wait()
+
+	// Only one re-authentication should happen for all the requests hitting the expired session.
+	if failures != 0 || atomic.LoadInt32(logins) != 2 {
+		t.Fail()
+	}
+}
+
+func TestClient_AutoReauth_noLogin(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.SetAutoReauth(true)
+	client.SetSidLoc("expired", "https://na0-api.salesforce.com")
+	registerSessionCheckingQueryMock(client, "session2")
+
+	if _, err := client.Query("SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestClient_Logout(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	logouts := 0
+	mockURL := "https://na0-api.salesforce.com/services/Soap/u/" + client.apiVersion
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("SOAPAction") != "logout" {
+			return httpmock.NewStringResponse(500, ""), nil
+		}
+		logouts++
+		return httpmock.NewStringResponse(200, `<?xml version="1.0" encoding="UTF-8"?>
+			<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">
+				<soapenv:Body><logoutResponse/></soapenv:Body>
+			</soapenv:Envelope>`), nil
+	})
+
+	if err := client.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if logouts != 1 || client.isLoggedIn() || client.GetLoc() != "" {
+		t.Fail()
+	}
+
+	// Already logged out.
+	if client.Logout() != ErrAuthentication {
+		t.Fail()
+	}
+}
+
+func TestClient_QueryContext_canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	registerSessionCheckingQueryMock(client, "sessionId")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.QueryContext(ctx, "SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+	if _, err := client.QueryContext(context.Background(), "SELECT Id FROM Case"); err != nil {
+		t.Fail()
+	}
+}
+
+func TestClient_Query_decimals(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	baseURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion
+	httpmock.RegisterResponder("GET", baseURL+"/query?q=SELECT%20Amount__c%2C%20Units__c%20FROM%20Opportunity",
+		httpmock.NewStringResponder(200, `{"totalSize": 1, "done": true,
+			"records": [{"Amount__c": 1234567890123456.78, "Units__c": 123456789012345678}]}`))
+
+	// Numbers are decoded as float64 by default.
+	result, err := client.Query("SELECT Amount__c, Units__c FROM Opportunity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.Records[0].InterfaceField("Units__c").(float64); !ok {
+		t.Error(result.Records[0])
+	}
+
+	client.SetUseNumber(true)
+	result, err = client.Query("SELECT Amount__c, Units__c FROM Opportunity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount, _ := new(big.Rat).SetString("1234567890123456.78")
+	if d, err := result.Records[0].DecimalField("Amount__c"); err != nil || d.Cmp(amount) != 0 {
+		t.Error(d, err)
+	}
+	if n, err := result.Records[0].IntField("Units__c"); err != nil || n != 123456789012345678 {
+		t.Error(n, err)
+	}
+	var opportunity struct {
+		Units int64 `sf:"Units__c"`
+	}
+	if err := result.Records[0].Decode(&opportunity); err != nil || opportunity.Units != 123456789012345678 {
+		t.Error(opportunity, err)
+	}
+}
+
+func TestClient_QueryAll(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	baseURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion
+	httpmock.RegisterResponder("GET", baseURL+"/queryAll?q=SELECT%20Id%20FROM%20Case%20WHERE%20IsDeleted%20=%20true",
+		httpmock.NewStringResponder(200, `{"totalSize": 2, "done": false, "nextRecordsUrl": "/services/data/v43.0/query/01gxx-1",
+			"records": [{"Id": "500xx000001", "IsDeleted": true}]}`))
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v43.0/query/01gxx-1",
+		httpmock.NewStringResponder(200, `{"totalSize": 2, "done": true, "records": [{"Id": "500xx000002", "IsDeleted": true}]}`))
+
+	result, err := client.QueryAll("SELECT Id FROM Case WHERE IsDeleted = true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Done || result.Records[0].InterfaceField("IsDeleted") != true {
+		t.Fail()
+	}
+	result, err = client.QueryAll(result.NextRecordsURL)
+	if err != nil || !result.Done || result.Records[0].ID() != "500xx000002" {
+		t.Fail()
+	}
+}
+
+func TestClient_Query_cassette(t *testing.T) {
+	recorder, err := cassette.New("testdata/cassettes/query_contacts.json", cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	client.SetHttpClient(recorder.Client())
+	if err := client.LoginPassword("user@example.com", "password", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.Query("SELECT Id, LastName, Email FROM Contact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalSize != 1 || result.Records[0].StringField("LastName") != "Doe" {
+		t.Fail()
+	}
+}
+
+func TestClient_DescribeGlobalContext(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/sobjects",
+		httpmock.NewStringResponder(200, `{"encoding": "UTF-8", "maxBatchSize": 200, "sobjects": [{"name": "Account"}]}`))
+
+	meta, err := client.DescribeGlobalContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*meta)["encoding"] != "UTF-8" {
+		t.Fail()
+	}
+}
+
+func TestClient_DownloadFileContext(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/ContentVersion/068xx/VersionData"
+	httpmock.RegisterResponder("GET", mockURL, httpmock.NewStringResponder(200, "file content"))
+	httpmock.RegisterResponder("GET", strings.Replace(mockURL, "068xx", "068yy", 1),
+		httpmock.NewStringResponder(404, `[{"message":"The requested resource does not exist","errorCode":"NOT_FOUND"}]`))
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := client.DownloadFileContext(context.Background(), "068xx", path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || string(data) != "file content" {
+		t.Fail()
+	}
+
+	if err := client.DownloadFileContext(context.Background(), "068yy", path); err == nil {
+		t.Fail()
+	}
+}
+
 func TestMain(m *testing.M) {
 	m.Run()
 }
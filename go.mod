@@ -0,0 +1,8 @@
+module github.com/simpleforce/simpleforce
+
+go 1.21
+
+require (
+	github.com/jarcoal/httpmock v1.0.4
+	github.com/pkg/errors v0.9.1
+)
@@ -0,0 +1,121 @@
+package simpleforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Identity describes the user the client is logged in as.
+type Identity struct {
+	UserID    string
+	OrgID     string
+	Username  string
+	FullName  string
+	Email     string
+	ProfileID string
+	Locale    string
+	TimeZone  string
+	UserType  string
+	IsSandbox bool
+}
+
+// identityResponse holds the response data from either the OAuth identity URL or the OpenID Connect userinfo endpoint,
+// which name some of the fields differently.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_using_openid.htm
+type identityResponse struct {
+	UserID            string `json:"user_id"`
+	OrgID             string `json:"organization_id"`
+	Username          string `json:"username"`
+	PreferredUsername string `json:"preferred_username"`
+	DisplayName       string `json:"display_name"`
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	Locale            string `json:"locale"`
+	Timezone          string `json:"timezone"`
+	ZoneInfo          string `json:"zoneinfo"`
+	UserType          string `json:"user_type"`
+}
+
+// CurrentUser returns the identity of the logged in user. The identity is taken from the SOAP login response if
+// LoginPassword was used; otherwise it is loaded from the OAuth identity URL (or the userinfo endpoint if the identity
+// URL is unknown) and the org and user records on first use.
+func (client *Client) CurrentUser() (*Identity, error) {
+	return client.CurrentUserContext(context.Background())
+}
+
+// CurrentUserContext returns the identity of the logged in user with the context.
+func (client *Client) CurrentUserContext(ctx context.Context) (*Identity, error) {
+	ctx = withOperation(ctx, "CurrentUser", ResourceIdentity, "")
+
+	if !client.isLoggedIn() {
+		return nil, ErrAuthentication
+	}
+
+	client.mu.RLock()
+	user, identityURL, loaded := client.user, client.identityURL, client.identityLoaded
+	client.mu.RUnlock()
+	if loaded {
+		return &user, nil
+	}
+
+	if identityURL == "" {
+		identityURL = fmt.Sprintf("%s/services/oauth2/userinfo", client.GetLoc())
+	}
+	data, err := client.httpRequestContext(ctx, http.MethodGet, identityURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp identityResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	user = Identity{
+		UserID:   resp.UserID,
+		OrgID:    resp.OrgID,
+		Username: firstNonEmpty(resp.Username, resp.PreferredUsername),
+		FullName: firstNonEmpty(resp.DisplayName, resp.Name),
+		Email:    resp.Email,
+		Locale:   resp.Locale,
+		TimeZone: firstNonEmpty(resp.Timezone, resp.ZoneInfo),
+		UserType: resp.UserType,
+	}
+
+	// Profile and sandbox flag are not part of the identity, query them from the records. The data API is queried even
+	// if the client uses the Tooling API, which doesn't expose these objects.
+	queryCtx := withOperation(ctx, "CurrentUser", ResourceQuery, "")
+	result, err := client.query(queryCtx, "query", fmt.Sprintf("SELECT ProfileId FROM User WHERE Id = '%s'", user.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Records) > 0 {
+		user.ProfileID = result.Records[0].StringField("ProfileId")
+	}
+	result, err = client.query(queryCtx, "query", "SELECT IsSandbox FROM Organization")
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Records) > 0 {
+		user.IsSandbox, _ = result.Records[0].InterfaceField("IsSandbox").(bool)
+	}
+
+	client.mu.Lock()
+	client.user = user
+	client.identityLoaded = true
+	client.mu.Unlock()
+
+	return &user, nil
+}
+
+// firstNonEmpty returns the first of values which is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package simpleforce
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_CurrentUser_soap(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	if _, err := client.CurrentUser(); err != ErrAuthentication {
+		t.Fail()
+	}
+
+	loginResp := `<?xml version="1.0" encoding="utf-8" ?>
+		<env:Envelope>
+			<env:Body>
+				<env:loginResponse>
+					<env:result>
+						<env:sandbox>true</env:sandbox>
+						<env:serverUrl>https://na0-api.salesforce.com/services/Soap/c/2.5</env:serverUrl>
+						<env:sessionId>sessionId</env:sessionId>
+						<env:userId>005xx000001SwiUAAS</env:userId>
+						<env:userInfo>
+							<env:organizationId>00Dxx0000001gPLEAY</env:organizationId>
+							<env:profileId>00exx000000j3lGAAQ</env:profileId>
+							<env:userEmail>user@example.com</env:userEmail>
+							<env:userFullName>Jane Doe</env:userFullName>
+							<env:userLocale>en_US</env:userLocale>
+							<env:userName>user@example.com.sandbox</env:userName>
+							<env:userTimeZone>America/Los_Angeles</env:userTimeZone>
+							<env:userType>Standard</env:userType>
+						</env:userInfo>
+					</env:result>
+				</env:loginResponse>
+			</env:Body>
+		</env:Envelope>`
+	httpmock.RegisterResponder("POST", "https://login.salesforce.com//services/Soap/u/"+client.apiVersion,
+		httpmock.NewStringResponder(200, loginResp))
+
+	if err := client.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// No further call is needed.
+	httpmock.Reset()
+	user, err := client.CurrentUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Identity{
+		UserID:    "005xx000001SwiUAAS",
+		OrgID:     "00Dxx0000001gPLEAY",
+		Username:  "user@example.com.sandbox",
+		FullName:  "Jane Doe",
+		Email:     "user@example.com",
+		ProfileID: "00exx000000j3lGAAQ",
+		Locale:    "en_US",
+		TimeZone:  "America/Los_Angeles",
+		UserType:  "Standard",
+		IsSandbox: true,
+	}
+	if *user != expected {
+		t.Fail()
+	}
+}
+
+func TestClient_CurrentUser_oauth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", mockTokenURL, httpmock.NewStringResponder(200, `{
+		"access_token": "accessToken",
+		"instance_url": "https://na0-api.salesforce.com",
+		"id": "https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS"
+	}`))
+	httpmock.RegisterResponder("GET", "https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS",
+		httpmock.NewStringResponder(200, `{
+			"user_id": "005xx000001SwiUAAS",
+			"organization_id": "00Dxx0000001gPLEAY",
+			"username": "user@example.com",
+			"display_name": "Jane Doe",
+			"email": "user@example.com",
+			"timezone": "Europe/Paris",
+			"user_type": "STANDARD",
+			"locale": "fr_FR"
+		}`))
+	queryURL := "https://na0-api.salesforce.com/services/data/v" + DefaultAPIVersion + "/query?q="
+	httpmock.RegisterResponder("GET", queryURL+"SELECT%20ProfileId%20FROM%20User%20WHERE%20Id%20=%20%27005xx000001SwiUAAS%27",
+		httpmock.NewStringResponder(200, `{"totalSize": 1, "done": true, "records": [{"ProfileId": "00exx000000j3lGAAQ"}]}`))
+	httpmock.RegisterResponder("GET", queryURL+"SELECT%20IsSandbox%20FROM%20Organization",
+		httpmock.NewStringResponder(200, `{"totalSize": 1, "done": true, "records": [{"IsSandbox": false}]}`))
+
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	if err := client.LoginJWT("consumerKey", generateTestKeyPEM(t), "user@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user and org records are queried from the data API even when the client uses the Tooling API.
+	client.Tooling()
+	user, err := client.CurrentUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserID != "005xx000001SwiUAAS" || user.OrgID != "00Dxx0000001gPLEAY" || user.FullName != "Jane Doe" {
+		t.Fail()
+	}
+	if user.ProfileID != "00exx000000j3lGAAQ" || user.TimeZone != "Europe/Paris" || user.Locale != "fr_FR" || user.IsSandbox {
+		t.Fail()
+	}
+
+	// The identity is loaded once.
+	httpmock.Reset()
+	if user, err = client.CurrentUser(); err != nil || user.Username != "user@example.com" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,101 @@
+package simpleforce
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the metrics recorded by the instrumentation middleware.
+const (
+	MetricCallDuration = "salesforce.call.duration"
+	MetricCalls        = "salesforce.calls"
+	MetricCallErrors   = "salesforce.call.errors"
+)
+
+// Tracer starts spans, e.g. an adapter to an OpenTelemetry tracer.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of work started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// SetError marks the span as failed.
+	SetError(err error)
+	End()
+}
+
+// Metrics records measurements, e.g. an adapter to an OpenTelemetry meter or a Prometheus registry.
+type Metrics interface {
+	// RecordDuration adds a value to the histogram name.
+	RecordDuration(name string, duration time.Duration, attributes map[string]string)
+	// IncCounter increments the counter name.
+	IncCounter(name string, attributes map[string]string)
+}
+
+// Instrumentation returns a middleware emitting a span and metrics for every call. Spans are named by operation and
+// sObject type, e.g. "Create Contact", and carry the API version, the HTTP status and the salesforce error code.
+// The latency histogram (MetricCallDuration) and the counters (MetricCalls, MetricCallErrors) are recorded with the
+// operation, sObject type, status and error code as attributes. Either tracer or metrics may be nil.
+//
+//	client.Use(simpleforce.Instrumentation(tracer, metrics))
+func Instrumentation(tracer Tracer, metrics Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) *CallResult {
+			var span Span
+			if tracer != nil {
+				var ctx context.Context
+				ctx, span = tracer.Start(call.Request.Context(), spanName(call))
+				call.Request = call.Request.WithContext(ctx)
+				span.SetAttribute("salesforce.operation", call.Operation)
+				span.SetAttribute("salesforce.resource", call.Resource)
+				span.SetAttribute("salesforce.api_version", call.APIVersion)
+				span.SetAttribute("salesforce.tooling", call.Tooling)
+				span.SetAttribute("http.method", call.Request.Method)
+				if call.SObjectType != "" {
+					span.SetAttribute("salesforce.sobject_type", call.SObjectType)
+				}
+			}
+
+			result := next(call)
+
+			if span != nil {
+				span.SetAttribute("http.status_code", result.StatusCode)
+				if result.ErrorCode != "" {
+					span.SetAttribute("salesforce.error_code", result.ErrorCode)
+				}
+				if result.Err != nil {
+					span.SetError(result.Err)
+				}
+				span.End()
+			}
+			if metrics != nil {
+				attributes := map[string]string{
+					"operation":   call.Operation,
+					"resource":    call.Resource,
+					"sobject":     call.SObjectType,
+					"status_code": strconv.Itoa(result.StatusCode),
+					"error_code":  result.ErrorCode,
+				}
+				metrics.RecordDuration(MetricCallDuration, result.Duration, attributes)
+				metrics.IncCounter(MetricCalls, attributes)
+				if result.Err != nil {
+					metrics.IncCounter(MetricCallErrors, attributes)
+				}
+			}
+			return result
+		}
+	}
+}
+
+// spanName names the span of a call by operation and sObject type.
+func spanName(call *Call) string {
+	name := call.Operation
+	if name == "" {
+		name = call.Request.Method
+	}
+	return strings.TrimSpace(name + " " + call.SObjectType)
+}
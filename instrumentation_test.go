@@ -0,0 +1,102 @@
+package simpleforce
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// memorySpan is a Span recorded in memory.
+type memorySpan struct {
+	name       string
+	parent     *memorySpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (span *memorySpan) SetAttribute(key string, value interface{}) { span.attributes[key] = value }
+func (span *memorySpan) SetError(err error)                         { span.err = err }
+func (span *memorySpan) End()                                       { span.ended = true }
+
+type memorySpanKey struct{}
+
+// memoryTracer records the spans started.
+type memoryTracer struct {
+	mu    sync.Mutex
+	spans []*memorySpan
+}
+
+func (tracer *memoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	parent, _ := ctx.Value(memorySpanKey{}).(*memorySpan)
+	span := &memorySpan{name: name, parent: parent, attributes: make(map[string]interface{})}
+	tracer.spans = append(tracer.spans, span)
+	return context.WithValue(ctx, memorySpanKey{}, span), span
+}
+
+// memoryMetrics records the measurements.
+type memoryMetrics struct {
+	mu        sync.Mutex
+	durations map[string][]time.Duration
+	counters  map[string]int
+	last      map[string]string
+}
+
+func (metrics *memoryMetrics) RecordDuration(name string, duration time.Duration, attributes map[string]string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.durations[name] = append(metrics.durations[name], duration)
+	metrics.last = attributes
+}
+
+func (metrics *memoryMetrics) IncCounter(name string, attributes map[string]string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.counters[name]++
+}
+
+func TestInstrumentation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tracer := &memoryTracer{}
+	metrics := &memoryMetrics{durations: make(map[string][]time.Duration), counters: make(map[string]int)}
+	client := requireClient(t, true)
+	client.Use(Instrumentation(tracer, metrics))
+	registerSessionCheckingQueryMock(client, "sessionId")
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/sobjects/Contact/003xx",
+		httpmock.NewStringResponder(404, `[{"message":"The requested resource does not exist","errorCode":"NOT_FOUND"}]`))
+
+	ctx, root := tracer.Start(context.Background(), "job")
+	if _, err := client.QueryContext(ctx, "SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if client.SObject("Contact").GetContext(ctx, "003xx") == nil {
+		t.Fail()
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatal(len(tracer.spans))
+	}
+	query, get := tracer.spans[1], tracer.spans[2]
+	if query.name != "Query" || query.parent != root || !query.ended || query.err != nil ||
+		query.attributes["http.status_code"] != 200 || query.attributes["salesforce.api_version"] != client.apiVersion {
+		t.Error(query)
+	}
+	if get.name != "Get Contact" || get.err == nil || get.attributes["salesforce.error_code"] != "NOT_FOUND" ||
+		get.attributes["salesforce.sobject_type"] != "Contact" {
+		t.Error(get)
+	}
+
+	if metrics.counters[MetricCalls] != 2 || metrics.counters[MetricCallErrors] != 1 || len(metrics.durations[MetricCallDuration]) != 2 {
+		t.Fail()
+	}
+	if metrics.last["operation"] != "Get" || metrics.last["status_code"] != "404" || metrics.last["error_code"] != "NOT_FOUND" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,23 @@
+// Package secrets finds the credentials embedded in strings, such as request and response bodies, so that they are kept
+// out of logs and cassettes.
+package secrets
+
+import "regexp"
+
+// patterns match salesforce session IDs ("<org id>!<token>"), and session IDs, tokens and passwords in SOAP, JSON or
+// form encoded bodies. The first group, if any, is kept.
+var patterns = []*regexp.Regexp{
+	regexp.MustCompile(`00D[0-9A-Za-z]{12,15}![0-9A-Za-z._]+`),
+	regexp.MustCompile(`(?i)(<(?:\w+:)?(?:sessionId|password)>)[^<]*`),
+	regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|accessToken|refreshToken|sessionId|password)"\s*:\s*")[^"]*`),
+	regexp.MustCompile(`(?i)\b((?:access_token|refresh_token|client_secret|password|assertion|code|code_verifier|token)=)[^&\s]+`),
+	regexp.MustCompile(`(?i)(Bearer )\S+`),
+}
+
+// Redact replaces the credentials found in s with replacement.
+func Redact(s, replacement string) string {
+	for _, pattern := range patterns {
+		s = pattern.ReplaceAllString(s, "${1}"+replacement)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package simpleforce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Names of commonly checked org limits.
+const (
+	LimitDailyApiRequests    = "DailyApiRequests"
+	LimitDailyBulkApiBatches = "DailyBulkApiBatches"
+	LimitDataStorageMB       = "DataStorageMB"
+	LimitFileStorageMB       = "FileStorageMB"
+)
+
+// ErrInsufficientLimit is returned by CheckLimits when a limit has less remaining than required.
+var ErrInsufficientLimit = errors.New("insufficient limit remaining")
+
+// Limits maps the name of each org limit, e.g. DailyApiRequests, to its value.
+type Limits map[string]Limit
+
+// Limit is the maximum and remaining value of an org limit. Some limits are broken down further, e.g. the
+// DailyApiRequests used by each connected app.
+type Limit struct {
+	Max       int
+	Remaining int
+	// Breakdown maps the name of each part, e.g. a connected app, to its own limit.
+	Breakdown map[string]Limit
+}
+
+// Used returns the consumed part of the limit.
+func (limit Limit) Used() int {
+	return limit.Max - limit.Remaining
+}
+
+// UnmarshalJSON decodes the Max and Remaining values of the limit, and the other entries into Breakdown.
+func (limit *Limit) UnmarshalJSON(data []byte) error {
+	var entries map[string]json.RawMessage
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	*limit = Limit{}
+	for key, value := range entries {
+		switch key {
+		case "Max":
+			err = json.Unmarshal(value, &limit.Max)
+		case "Remaining":
+			err = json.Unmarshal(value, &limit.Remaining)
+		default:
+			var part Limit
+			err = json.Unmarshal(value, &part)
+			if limit.Breakdown == nil {
+				limit.Breakdown = make(map[string]Limit)
+			}
+			limit.Breakdown[key] = part
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse limit %s", key)
+		}
+	}
+	return nil
+}
+
+// Check returns ErrInsufficientLimit, listing every failing limit, if any of the required limits has less remaining
+// than required. A limit missing from the org is reported as insufficient.
+func (limits Limits) Check(required map[string]int) error {
+	var failures []string
+	for name, amount := range required {
+		limit, ok := limits[name]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%s: unknown limit", name))
+		} else if limit.Remaining < amount {
+			failures = append(failures, fmt.Sprintf("%s: %d remaining, %d required", name, limit.Remaining, amount))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Strings(failures)
+	return errors.Wrap(ErrInsufficientLimit, strings.Join(failures, "; "))
+}
+
+// Limits gets the limits of the org, such as the daily API requests and the data storage.
+// Ref: https://developer.salesforce.com/docs/atlas.en-us.api_rest.meta/api_rest/resources_limits.htm
+func (client *Client) Limits() (Limits, error) {
+	return client.LimitsContext(context.Background())
+}
+
+// LimitsContext gets the limits of the org with the context.
+func (client *Client) LimitsContext(ctx context.Context) (Limits, error) {
+	ctx = withOperation(ctx, "Limits", ResourceLimits, "")
+
+	if !client.isLoggedIn() {
+		return nil, ErrAuthentication
+	}
+
+	url := client.makeURL("limits")
+	respData, err := client.httpRequestContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits Limits
+	err = json.Unmarshal(respData, &limits)
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// CheckLimits gets the limits of the org and checks that enough remains of each required limit, e.g. before
+// starting a job:
+//
+//	err := client.CheckLimits(map[string]int{simpleforce.LimitDailyApiRequests: 5000})
+func (client *Client) CheckLimits(required map[string]int) error {
+	return client.CheckLimitsContext(context.Background(), required)
+}
+
+// CheckLimitsContext gets the limits of the org and checks that enough remains of each required limit with the
+// context.
+func (client *Client) CheckLimitsContext(ctx context.Context, required map[string]int) error {
+	limits, err := client.LimitsContext(ctx)
+	if err != nil {
+		return err
+	}
+	return limits.Check(required)
+}
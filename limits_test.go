@@ -0,0 +1,44 @@
+package simpleforce
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+)
+
+func TestClient_Limits(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/limits",
+		httpmock.NewStringResponder(200, `{
+			"DailyApiRequests": {"Max": 15000, "Remaining": 14000, "Ant Migration Tool": {"Max": 0, "Remaining": 0},
+				"Salesforce CLI": {"Max": 0, "Remaining": 0}},
+			"DailyBulkApiBatches": {"Max": 15000, "Remaining": 100},
+			"DataStorageMB": {"Max": 5, "Remaining": 4}
+		}`))
+
+	limits, err := client.Limits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := limits[LimitDailyApiRequests]
+	if api.Max != 15000 || api.Used() != 1000 || len(api.Breakdown) != 2 || api.Breakdown["Salesforce CLI"].Max != 0 {
+		t.Fail()
+	}
+	if limits[LimitDataStorageMB].Breakdown != nil {
+		t.Fail()
+	}
+
+	if err := client.CheckLimits(map[string]int{LimitDailyApiRequests: 5000, LimitDataStorageMB: 1}); err != nil {
+		t.Fatal(err)
+	}
+	err = client.CheckLimits(map[string]int{LimitDailyBulkApiBatches: 500, LimitFileStorageMB: 1})
+	if !errors.Is(err, ErrInsufficientLimit) || !strings.Contains(err.Error(), "DailyBulkApiBatches: 100 remaining, 500 required") ||
+		!strings.Contains(err.Error(), "FileStorageMB: unknown limit") {
+		t.Error(err)
+	}
+}
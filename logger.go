@@ -0,0 +1,128 @@
+package simpleforce
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/simpleforce/simpleforce/internal/secrets"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// String returns the name of the level, e.g. "INFO".
+func (level LogLevel) String() string {
+	switch level {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(level))
+}
+
+// Logger receives the log messages of the client. keysAndValues holds alternating keys and values, in the style of
+// log/slog. Session IDs, tokens and passwords are redacted before they reach the Logger.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Log(level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+// SetLogger sets the logger of the client. Nothing is logged if logger is nil, which is the default.
+func (client *Client) SetLogger(logger Logger) {
+	client.logger = logger
+}
+
+// StdLogger is a Logger writing messages at or above a minimum level to a standard library logger, one line per
+// message: "[simpleforce] INFO msg key=value ...".
+type StdLogger struct {
+	logger   *log.Logger
+	minLevel LogLevel
+}
+
+// NewStdLogger creates a StdLogger writing messages at or above minLevel to logger, or to the standard logger of the
+// log package if logger is nil.
+func NewStdLogger(logger *log.Logger, minLevel LogLevel) *StdLogger {
+	return &StdLogger{logger: logger, minLevel: minLevel}
+}
+
+// Log writes the message if its level is at or above the minimum level.
+func (logger *StdLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	if level < logger.minLevel {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(logPrefix + " " + level.String() + " " + msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{} = "(missing)"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		fmt.Fprintf(&b, " %v=%v", keysAndValues[i], value)
+	}
+
+	if logger.logger != nil {
+		logger.logger.Print(b.String())
+	} else {
+		log.Print(b.String())
+	}
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys lists the log keys whose values are never logged.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"sessionid":     true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"authorization": true,
+	"clientsecret":  true,
+}
+
+// log sends the message to the logger of the client, if any, after redacting credentials.
+func (client *Client) log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	if client == nil || client.logger == nil {
+		return
+	}
+
+	redactedValues := make([]interface{}, len(keysAndValues))
+	for i, value := range keysAndValues {
+		if i%2 == 1 {
+			if key, ok := keysAndValues[i-1].(string); ok && sensitiveKeys[strings.ToLower(key)] {
+				redactedValues[i] = redacted
+				continue
+			}
+		}
+		switch v := value.(type) {
+		case string:
+			redactedValues[i] = redact(v)
+		case []byte:
+			redactedValues[i] = redact(string(v))
+		case error:
+			redactedValues[i] = redact(v.Error())
+		default:
+			redactedValues[i] = value
+		}
+	}
+	client.logger.Log(level, redact(msg), redactedValues...)
+}
+
+// redact replaces the credentials embedded in s, such as a response body.
+func redact(s string) string {
+	return secrets.Redact(s, redacted)
+}
@@ -0,0 +1,35 @@
+package simpleforce
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger is a Logger forwarding messages to a slog.Handler.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a Logger forwarding messages to handler, e.g. to emit JSON logs with
+// slog.NewJSONHandler(os.Stderr, nil).
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &slogLogger{logger: slog.New(handler)}
+}
+
+// Log forwards the message at the matching slog level.
+func (logger *slogLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	logger.logger.Log(context.Background(), slogLevel(level), msg, keysAndValues...)
+}
+
+// slogLevel converts level to a slog level.
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
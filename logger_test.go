@@ -0,0 +1,131 @@
+package simpleforce
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// recordingLogger keeps the formatted log messages.
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (logger *recordingLogger) Log(level LogLevel, msg string, keysAndValues ...interface{}) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.messages = append(logger.messages, fmt.Sprint(level, " ", msg, " ", keysAndValues))
+}
+
+func TestClient_SetLogger(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	logger := &recordingLogger{}
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.SetLogger(logger)
+	registerLoginMock(client)
+	if err := client.LoginPassword("user@example.com", "s3cr3t", "t0k3n"); err != nil {
+		t.Fatal(err)
+	}
+	client.SetSidLoc("00Dxx0000001gPL!AQ4AQFb1x.2kVlvZ", client.GetLoc())
+
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, httpmock.NewStringResponder(400,
+		`[{"message":"Session 00Dxx0000001gPL!AQ4AQFb1x.2kVlvZ is not allowed","errorCode":"INVALID_OPERATION"}]`))
+	if _, err := client.Query("SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+
+	all := strings.Join(logger.messages, "\n")
+	if !strings.Contains(all, "user authenticated") || !strings.Contains(all, "INVALID_OPERATION") {
+		t.Error(all)
+	}
+	if strings.Contains(all, "s3cr3t") || strings.Contains(all, "AQ4AQFb1x") {
+		t.Error(all)
+	}
+}
+
+func TestClient_SetLogger_recordData(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	logger := &recordingLogger{}
+	client := requireClient(t, true)
+	client.SetLogger(logger)
+
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Contact/"
+	httpmock.RegisterResponder("POST", mockURL, httpmock.NewStringResponder(400, `[{
+		"message": "Use one of these records?",
+		"errorCode": "DUPLICATES_DETECTED",
+		"duplicateResult": {"matchResults": [{"matchRecords": [{"record": {"Email": "jane.doe@example.com"}}]}]}
+	}]`))
+	httpmock.RegisterResponder("POST", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/sobjects/Lead/",
+		httpmock.NewStringResponder(201, `{"id": "", "success": false, "errors": [
+			{"statusCode": "STRING_TOO_LONG", "message": "Company: data value too large", "fields": ["Company"]}
+		], "record": {"Email": "john.doe@example.com"}}`))
+
+	for _, typeName := range []string{"Contact", "Lead"} {
+		obj := client.SObject(typeName)
+		obj.Set("Email", "doe@example.com")
+		if obj.Create() == nil {
+			t.Error(typeName)
+		}
+	}
+
+	// The errors are logged, but not the record data of the responses.
+	all := strings.Join(logger.messages, "\n")
+	if !strings.Contains(all, "DUPLICATES_DETECTED: Use one of these records?") || !strings.Contains(all, "STRING_TOO_LONG") {
+		t.Error(all)
+	}
+	if strings.Contains(all, "example.com") {
+		t.Error(all)
+	}
+}
+
+func TestClient_logSilentByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.log(LogLevelError, "message")
+	if buf.Len() != 0 {
+		t.Fail()
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.SetLogger(NewStdLogger(log.New(&buf, "", 0), LogLevelInfo))
+
+	client.log(LogLevelDebug, "hidden")
+	client.log(LogLevelInfo, "logged in", "username", "user@example.com", "password", "s3cr3t",
+		"body", []byte(`<sessionId>00Dxx0000001gPL!AQ4AQFb1x</sessionId>`))
+	if buf.String() != "[simpleforce] INFO logged in username=user@example.com password=[REDACTED] "+
+		"body=<sessionId>[REDACTED]</sessionId>\n" {
+		t.Error(buf.String())
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cases := map[string]string{
+		`{"access_token":"abc","instance_url":"x"}`:      `{"access_token":"[REDACTED]","instance_url":"x"}`,
+		"grant_type=refresh_token&refresh_token=abc&x=1": "grant_type=refresh_token&refresh_token=[REDACTED]&x=1",
+		"Authorization: Bearer abc.def":                  "Authorization: Bearer [REDACTED]",
+		"session 00Dxx0000001gPL!AQ4AQFb1x expired":      "session [REDACTED] expired",
+		"errorCode=INVALID_FIELD":                        "errorCode=INVALID_FIELD",
+	}
+	for in, want := range cases {
+		if got := redact(in); got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Resource kinds of the calls passed to middlewares.
+const (
+	ResourceLogin    = "login"    // SOAP login and logout, OAuth token requests
+	ResourceQuery    = "query"    // SOQL queries
+	ResourceSObject  = "sobject"  // record create, get, update, upsert and delete
+	ResourceDescribe = "describe" // object and global describes
+	ResourceFile     = "file"     // file downloads
+	ResourceApex     = "apex"     // anonymous apex
+	ResourceLimits   = "limits"   // org limits
+	ResourceIdentity = "identity" // user identity
+)
+
+// Call describes a request sent to salesforce, as seen by middlewares.
+type Call struct {
+	// Operation is the name of the client method making the call, e.g. "Query" or "Create".
+	Operation string
+	// Resource is the kind of resource called, e.g. ResourceQuery.
+	Resource string
+	// SObjectType is the type of the record for sobject and describe calls.
+	SObjectType string
+	// Tooling is set for calls to the Tooling API.
+	Tooling bool
+	// APIVersion is the API version of the client, e.g. "43.0".
+	APIVersion string
+	// Request is the request about to be sent. Middlewares may add headers to it, or replace it, e.g. to attach
+	// values to its context.
+	Request *http.Request
+}
+
+// CallResult is the outcome of a call, as seen by middlewares.
+type CallResult struct {
+	// Response is the response received, if any. Its body is closed unless the call succeeded.
+	Response *http.Response
+	// Err is the error of the call. It is an *APIError if salesforce responded with an error.
+	Err error
+	// StatusCode is the HTTP status of the response, or 0 if none was received.
+	StatusCode int
+	// ErrorCode is the first error code reported by salesforce, e.g. "INVALID_FIELD".
+	ErrorCode string
+	// Duration is the time taken to send the request and receive the response headers, including retries and the
+	// time spent waiting for the rate limiter.
+	Duration time.Duration
+}
+
+// Handler sends a call and returns its outcome.
+type Handler func(call *Call) *CallResult
+
+// Middleware wraps the Handler sending a call, e.g. to add headers, logging, tracing or metrics. It must call next to
+// send the call, unless it returns an outcome of its own.
+type Middleware func(next Handler) Handler
+
+// Use adds middlewares to the client. The first middleware added is the outermost one, seeing each call first and
+// its outcome last. Middlewares must be added before the client is used.
+func (client *Client) Use(middlewares ...Middleware) {
+	client.middlewares = append(client.middlewares, middlewares...)
+	handler := Handler(client.sendCall)
+	for i := len(client.middlewares) - 1; i >= 0; i-- {
+		handler = client.middlewares[i](handler)
+	}
+	client.handler = handler
+}
+
+// operationKey is the context key of the operation making a call.
+type operationKey struct{}
+
+// operation describes the client method making a call, and is passed through the context of its requests.
+type operation struct {
+	name        string
+	resource    string
+	sObjectType string
+}
+
+// withOperation returns a context marking the requests made with it as part of the operation.
+func withOperation(ctx context.Context, name, resource, sObjectType string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{name: name, resource: resource, sObjectType: sObjectType})
+}
+
+// send is the common request path to the salesforce server. The request is passed through the middlewares of the
+// client, if any. A response with a 2xx status is returned as is and the caller must close its body; otherwise the
+// error is parsed from the response.
+func (client *Client) send(req *http.Request) (*http.Response, error) {
+	op, _ := req.Context().Value(operationKey{}).(operation)
+	call := &Call{
+		Operation:   op.name,
+		Resource:    op.resource,
+		SObjectType: op.sObjectType,
+		Tooling:     strings.Contains(req.URL.Path, "/tooling/"),
+		APIVersion:  client.apiVersion,
+		Request:     req,
+	}
+
+	handler := client.handler
+	if handler == nil {
+		handler = client.sendCall
+	}
+	result := handler(call)
+	return result.Response, result.Err
+}
+
+// sendCall is the innermost Handler, sending the request of the call with retries.
+func (client *Client) sendCall(call *Call) *CallResult {
+	start := time.Now()
+	resp, _, err := client.sendWithRetry(call.Request)
+	result := &CallResult{Response: resp, Err: err, Duration: time.Since(start)}
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	var apiError *APIError
+	if errors.As(err, &apiError) && len(apiError.Errors) > 0 {
+		result.ErrorCode = apiError.Errors[0].ErrorCode
+	}
+	return result
+}
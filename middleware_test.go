@@ -0,0 +1,64 @@
+package simpleforce
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_Use(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	registerLoginMock(client)
+	var order []string
+	var calls []Call
+	var results []CallResult
+	client.Use(func(next Handler) Handler {
+		return func(call *Call) *CallResult {
+			order = append(order, "outer")
+			call.Request.Header.Set("X-Request-Id", "req-1")
+			result := next(call)
+			calls = append(calls, *call)
+			results = append(results, *result)
+			return result
+		}
+	}, func(next Handler) Handler {
+		return func(call *Call) *CallResult {
+			order = append(order, "inner")
+			return next(call)
+		}
+	})
+
+	if err := client.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Contact/"
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Request-Id") != "req-1" {
+			return httpmock.NewStringResponse(500, ""), nil
+		}
+		return httpmock.NewStringResponse(400, `[{"message":"Contact: bad value","errorCode":"INVALID_FIELD"}]`), nil
+	})
+	if err := client.SObject("Contact").Create(); err == nil {
+		t.Fail()
+	}
+
+	if strings.Join(order, ",") != "outer,inner,outer,inner" || len(calls) != 2 {
+		t.Fatal(order)
+	}
+	if calls[0].Operation != "LoginPassword" || calls[0].Resource != ResourceLogin || results[0].StatusCode != 200 {
+		t.Fail()
+	}
+	if calls[1].Operation != "Create" || calls[1].Resource != ResourceSObject || calls[1].SObjectType != "Contact" ||
+		calls[1].Tooling {
+		t.Fail()
+	}
+	if results[1].StatusCode != 400 || results[1].ErrorCode != "INVALID_FIELD" || results[1].Err == nil ||
+		results[1].Duration <= 0 {
+		t.Fail()
+	}
+}
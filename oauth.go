@@ -0,0 +1,398 @@
+package simpleforce
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AudienceProduction is the JWT audience for production and developer edition orgs.
+	AudienceProduction = "https://login.salesforce.com"
+	// AudienceSandbox is the JWT audience for sandbox orgs.
+	AudienceSandbox = "https://test.salesforce.com"
+
+	jwtBearerGrantType         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	authorizationCodeGrantType = "authorization_code"
+	refreshTokenGrantType      = "refresh_token"
+	jwtExpiry                  = 3 * time.Minute
+	pkceVerifierBytes          = 32
+)
+
+// oauthTokenResponse holds the response data from the OAuth token endpoint.
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	InstanceURL  string `json:"instance_url"`
+	ID           string `json:"id"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	IssuedAt     string `json:"issued_at"`
+	Signature    string `json:"signature"`
+}
+
+// LoginJWT signs into salesforce using the OAuth 2.0 JWT bearer flow. consumerKey is the consumer key of a connected
+// app which has the certificate matching privateKeyPEM uploaded and username pre-authorized. audience should be
+// AudienceProduction or AudienceSandbox; the baseURL of the client is used if it is empty.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_oauth_jwt_flow.htm
+func (client *Client) LoginJWT(consumerKey string, privateKeyPEM []byte, username, audience string) error {
+	return client.LoginJWTContext(context.Background(), consumerKey, privateKeyPEM, username, audience)
+}
+
+// LoginJWTContext signs into salesforce using the OAuth 2.0 JWT bearer flow with the context.
+func (client *Client) LoginJWTContext(ctx context.Context, consumerKey string, privateKeyPEM []byte, username, audience string) error {
+	return client.authenticate(ctx, func(ctx context.Context) error {
+		return client.loginJWT(ctx, consumerKey, privateKeyPEM, username, audience)
+	})
+}
+
+// loginJWT runs the JWT bearer flow, without consulting the token store.
+func (client *Client) loginJWT(ctx context.Context, consumerKey string, privateKeyPEM []byte, username, audience string) error {
+	ctx = withOperation(ctx, "LoginJWT", ResourceLogin, "")
+
+	if audience == "" {
+		audience = strings.TrimRight(client.baseURL, "/")
+	}
+
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		client.log(LogLevelError, "error occurred parsing private key", "error", err)
+		return err
+	}
+
+	assertion, err := signJWT(key, map[string]interface{}{
+		"iss": consumerKey,
+		"sub": username,
+		"aud": audience,
+		"exp": time.Now().Add(jwtExpiry).Unix(),
+	})
+	if err != nil {
+		client.log(LogLevelError, "error occurred signing JWT", "error", err)
+		return err
+	}
+
+	token, err := client.requestToken(withIdempotent(ctx, true), url.Values{
+		"grant_type": {jwtBearerGrantType},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return err
+	}
+
+	client.applyToken(token)
+	client.mu.Lock()
+	client.user.Username = username
+	client.mu.Unlock()
+
+	client.log(LogLevelInfo, "user authenticated", "username", username)
+	return nil
+}
+
+// PKCE holds a Proof Key for Code Exchange verifier and its S256 challenge for the web server flow.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_pkce.htm
+type PKCE struct {
+	Verifier  string
+	Challenge string
+}
+
+// NewPKCE generates a random code verifier and its S256 challenge. The verifier must be kept (e.g. in the user's
+// session) between AuthorizeURL and LoginAuthorizationCode.
+func NewPKCE() (*PKCE, error) {
+	buf := make([]byte, pkceVerifierBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+	challenge := sha256.Sum256([]byte(verifier))
+	return &PKCE{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge[:]),
+	}, nil
+}
+
+// AuthorizeURL builds the URL to redirect the user to for the OAuth 2.0 web server (authorization code) flow. pkce is
+// optional but recommended; scopes are optional and the connected app's default scopes are used if omitted.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_oauth_web_server_flow.htm
+func (client *Client) AuthorizeURL(consumerKey, redirectURI, state string, pkce *PKCE, scopes ...string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {consumerKey},
+		"redirect_uri":  {redirectURI},
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	if pkce != nil {
+		query.Set("code_challenge", pkce.Challenge)
+		query.Set("code_challenge_method", "S256")
+	}
+	if len(scopes) > 0 {
+		query.Set("scope", strings.Join(scopes, " "))
+	}
+	return fmt.Sprintf("%s/services/oauth2/authorize?%s", strings.TrimRight(client.baseURL, "/"), query.Encode())
+}
+
+// LoginAuthorizationCode exchanges the authorization code received on redirectURI for an access token and a refresh
+// token. consumerSecret may be empty if the connected app doesn't require it, and codeVerifier must be the
+// PKCE.Verifier used to build the authorize URL, if any.
+func (client *Client) LoginAuthorizationCode(consumerKey, consumerSecret, redirectURI, code, codeVerifier string) error {
+	return client.LoginAuthorizationCodeContext(context.Background(), consumerKey, consumerSecret, redirectURI, code, codeVerifier)
+}
+
+// LoginAuthorizationCodeContext exchanges the authorization code for an access token and a refresh token with the
+// context.
+func (client *Client) LoginAuthorizationCodeContext(ctx context.Context, consumerKey, consumerSecret, redirectURI, code, codeVerifier string) error {
+	ctx = withOperation(ctx, "LoginAuthorizationCode", ResourceLogin, "")
+
+	form := url.Values{
+		"grant_type":   {authorizationCodeGrantType},
+		"code":         {code},
+		"client_id":    {consumerKey},
+		"redirect_uri": {redirectURI},
+	}
+	if consumerSecret != "" {
+		form.Set("client_secret", consumerSecret)
+	}
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+
+	token, err := client.requestToken(ctx, form)
+	if err != nil {
+		return err
+	}
+
+	client.mu.Lock()
+	client.oauth.consumerKey = consumerKey
+	client.oauth.consumerSecret = consumerSecret
+	client.mu.Unlock()
+	client.applyToken(token)
+
+	// The authorization code can only be used once, so further logins are done with the refresh token.
+	if token.RefreshToken != "" {
+		client.setLogin(client.refreshSession)
+	}
+	client.saveSession()
+
+	client.log(LogLevelInfo, "user authenticated", "userId", userIDFromIdentityURL(token.ID))
+	return nil
+}
+
+// LoginRefreshToken signs into salesforce using a refresh token obtained previously, e.g. from LoginAuthorizationCode.
+// The refresh token is kept by the client so RefreshSession can renew the session later.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_oauth_refresh_token_flow.htm
+func (client *Client) LoginRefreshToken(consumerKey, consumerSecret, refreshToken string) error {
+	return client.LoginRefreshTokenContext(context.Background(), consumerKey, consumerSecret, refreshToken)
+}
+
+// LoginRefreshTokenContext signs into salesforce using a refresh token with the context.
+func (client *Client) LoginRefreshTokenContext(ctx context.Context, consumerKey, consumerSecret, refreshToken string) error {
+	client.mu.Lock()
+	client.oauth.consumerKey = consumerKey
+	client.oauth.consumerSecret = consumerSecret
+	client.oauth.refreshToken = refreshToken
+	client.mu.Unlock()
+	return client.authenticate(ctx, client.refreshSession)
+}
+
+// RefreshSession acquires a new access token with the refresh token kept by the client.
+func (client *Client) RefreshSession() error {
+	return client.RefreshSessionContext(context.Background())
+}
+
+// RefreshSessionContext acquires a new access token with the refresh token kept by the client with the context.
+func (client *Client) RefreshSessionContext(ctx context.Context) error {
+	err := client.refreshSession(ctx)
+	if err != nil {
+		return err
+	}
+	client.saveSession()
+	return nil
+}
+
+// refreshSession runs the refresh token flow, without consulting the token store.
+func (client *Client) refreshSession(ctx context.Context) error {
+	ctx = withOperation(ctx, "RefreshSession", ResourceLogin, "")
+
+	client.mu.RLock()
+	oauth := client.oauth
+	client.mu.RUnlock()
+
+	if oauth.refreshToken == "" {
+		return errors.New("refresh token not set")
+	}
+
+	form := url.Values{
+		"grant_type":    {refreshTokenGrantType},
+		"refresh_token": {oauth.refreshToken},
+		"client_id":     {oauth.consumerKey},
+	}
+	if oauth.consumerSecret != "" {
+		form.Set("client_secret", oauth.consumerSecret)
+	}
+
+	token, err := client.requestToken(withIdempotent(ctx, true), form)
+	if err != nil {
+		return err
+	}
+
+	client.applyToken(token)
+
+	client.log(LogLevelInfo, "session refreshed", "userId", userIDFromIdentityURL(token.ID))
+	return nil
+}
+
+// GetRefreshToken exposes the refresh token to save in admin settings.
+func (client *Client) GetRefreshToken() string {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.oauth.refreshToken
+}
+
+// requestToken posts the form values to the OAuth token endpoint and returns the parsed token response.
+func (client *Client) requestToken(ctx context.Context, form url.Values) (*oauthTokenResponse, error) {
+	url := fmt.Sprintf("%s/services/oauth2/token", strings.TrimRight(client.baseURL, "/"))
+	req, err := newRequest(ctx, http.MethodPost, url, []byte(form.Encode()))
+	if err != nil {
+		client.log(LogLevelError, "error occurred creating token request", "error", err)
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := client.send(req)
+	if err != nil {
+		client.log(LogLevelError, "error occurred submitting token request", "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		client.log(LogLevelError, "error occurred reading token response", "error", err)
+		return nil, err
+	}
+
+	var token oauthTokenResponse
+	err = json.Unmarshal(respData, &token)
+	if err != nil {
+		client.log(LogLevelError, "error occurred parsing token response", "error", err)
+		return nil, err
+	}
+	if token.AccessToken == "" || token.InstanceURL == "" {
+		return nil, ErrAuthentication
+	}
+	return &token, nil
+}
+
+// applyToken updates the session of the client with the token returned from the OAuth token endpoint.
+func (client *Client) applyToken(token *oauthTokenResponse) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sessionID = token.AccessToken
+	client.instanceURL = parseHost(token.InstanceURL)
+	if token.ID != "" && token.ID != client.identityURL {
+		// Identity of a new user, to be loaded by CurrentUser.
+		client.identityURL = token.ID
+		client.user = Identity{UserID: userIDFromIdentityURL(token.ID)}
+		client.identityLoaded = false
+	}
+	client.oauthSession = true
+	if token.RefreshToken != "" {
+		// Refresh token is only returned by some flows, and is not rotated on refresh unless configured.
+		client.oauth.refreshToken = token.RefreshToken
+	}
+}
+
+// revokeToken revokes an OAuth access token or refresh token.
+// Ref: https://help.salesforce.com/articleView?id=remoteaccess_revoke_token.htm
+func (client *Client) revokeToken(ctx context.Context, token string) error {
+	ctx = withOperation(ctx, "Logout", ResourceLogin, "")
+
+	form := url.Values{"token": {token}}
+	url := fmt.Sprintf("%s/services/oauth2/revoke", strings.TrimRight(client.baseURL, "/"))
+	req, err := newRequest(withIdempotent(ctx, true), http.MethodPost, url, []byte(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.send(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// userIDFromIdentityURL extracts the user ID from an identity URL such as
+// https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS.
+func userIDFromIdentityURL(idURL string) string {
+	rIndex := strings.LastIndex(idURL, "/")
+	if rIndex == -1 {
+		return ""
+	}
+	return idURL[rIndex+1:]
+}
+
+// parseRSAPrivateKey decodes a PEM encoded RSA private key in either PKCS#1 or PKCS#8 form.
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse private key")
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT builds a compact RS256 signed JWT with the given claims.
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(payload))
+
+	hashed := sha256.Sum256(buf.Bytes())
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+	return buf.String(), nil
+}
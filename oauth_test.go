@@ -0,0 +1,245 @@
+package simpleforce
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const mockTokenURL = "https://login.salesforce.com/services/oauth2/token"
+
+func generateTestKeyPEM(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestClient_LoginJWT_success(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var claims map[string]interface{}
+	httpmock.RegisterResponder("POST", mockTokenURL, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		if req.PostForm.Get("grant_type") != jwtBearerGrantType {
+			return httpmock.NewStringResponse(400, `{"error":"unsupported_grant_type","error_description":"grant type not supported"}`), nil
+		}
+		parts := strings.Split(req.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			return httpmock.NewStringResponse(400, `{"error":"invalid_grant","error_description":"invalid assertion"}`), nil
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(payload, &claims)
+		return httpmock.NewStringResponse(200, `{
+			"access_token": "accessToken",
+			"scope": "api",
+			"instance_url": "https://na0-api.salesforce.com",
+			"id": "https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS",
+			"token_type": "Bearer"
+		}`), nil
+	})
+
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	err := client.LoginJWT("consumerKey", generateTestKeyPEM(t), "user@example.com", AudienceProduction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.sessionID != "accessToken" || client.instanceURL != "https://na0-api.salesforce.com" {
+		t.Fail()
+	}
+	if client.user.UserID != "005xx000001SwiUAAS" || client.user.Username != "user@example.com" {
+		t.Fail()
+	}
+	if claims["iss"] != "consumerKey" || claims["sub"] != "user@example.com" || claims["aud"] != AudienceProduction {
+		t.Fail()
+	}
+}
+
+func TestClient_LoginJWT_fail(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", mockTokenURL,
+		httpmock.NewStringResponder(400, `{"error":"invalid_grant","error_description":"user hasn't approved this consumer"}`))
+
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	err := client.LoginJWT("consumerKey", generateTestKeyPEM(t), "user@example.com", "")
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fail()
+	}
+	if client.isLoggedIn() {
+		t.Fail()
+	}
+}
+
+func TestClient_LoginJWT_badKey(t *testing.T) {
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	if client.LoginJWT("consumerKey", []byte("not a key"), "user@example.com", "") == nil {
+		t.Fail()
+	}
+}
+
+func TestClient_AuthorizeURL(t *testing.T) {
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	pkce, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := url.Parse(client.AuthorizeURL("consumerKey", "https://app.example.com/callback", "xyz", pkce, "api", "refresh_token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authURL.Host != "login.salesforce.com" || authURL.Path != "/services/oauth2/authorize" {
+		t.Fail()
+	}
+	query := authURL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != "consumerKey" || query.Get("state") != "xyz" {
+		t.Fail()
+	}
+	if query.Get("code_challenge") != pkce.Challenge || query.Get("code_challenge_method") != "S256" {
+		t.Fail()
+	}
+	if query.Get("scope") != "api refresh_token" {
+		t.Fail()
+	}
+
+	challenge := sha256.Sum256([]byte(pkce.Verifier))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != pkce.Challenge {
+		t.Fail()
+	}
+}
+
+func TestClient_LoginAuthorizationCode(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var form url.Values
+	httpmock.RegisterResponder("POST", mockTokenURL, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		form = req.PostForm
+		return httpmock.NewStringResponse(200, `{
+			"access_token": "accessToken",
+			"refresh_token": "refreshToken",
+			"instance_url": "https://na0-api.salesforce.com",
+			"id": "https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS",
+			"token_type": "Bearer"
+		}`), nil
+	})
+
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	err := client.LoginAuthorizationCode("consumerKey", "", "https://app.example.com/callback", "code", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("grant_type") != "authorization_code" || form.Get("code") != "code" || form.Get("code_verifier") != "verifier" {
+		t.Fail()
+	}
+	if form.Get("client_secret") != "" {
+		t.Fail()
+	}
+	if client.GetSid() != "accessToken" || client.GetRefreshToken() != "refreshToken" {
+		t.Fail()
+	}
+}
+
+func TestClient_LoginRefreshToken(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder("POST", mockTokenURL, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		if req.PostForm.Get("grant_type") != "refresh_token" || req.PostForm.Get("refresh_token") != "refreshToken" ||
+			req.PostForm.Get("client_secret") != "secret" {
+			return httpmock.NewStringResponse(400, `{"error":"invalid_grant","error_description":"expired access/refresh token"}`), nil
+		}
+		calls++
+		return httpmock.NewStringResponse(200, fmt.Sprintf(`{
+			"access_token": "accessToken%d",
+			"instance_url": "https://na0-api.salesforce.com",
+			"id": "https://login.salesforce.com/id/00Dxx0000001gPLEAY/005xx000001SwiUAAS"
+		}`, calls)), nil
+	})
+
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	if client.RefreshSession() == nil {
+		t.Fail()
+	}
+
+	err := client.LoginRefreshToken("consumerKey", "secret", "refreshToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "accessToken1" {
+		t.Fail()
+	}
+
+	err = client.RefreshSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "accessToken2" || client.GetRefreshToken() != "refreshToken" {
+		t.Fail()
+	}
+}
+
+func TestClient_Logout_revoke(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", mockTokenURL, httpmock.NewStringResponder(200, `{
+		"access_token": "accessToken",
+		"refresh_token": "refreshToken",
+		"instance_url": "https://na0-api.salesforce.com"
+	}`))
+	var revoked []string
+	httpmock.RegisterResponder("POST", "https://login.salesforce.com/services/oauth2/revoke", func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, req.PostForm.Get("token"))
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+
+	store := NewMemoryTokenStore()
+	client := NewClient(DefaultURL, DefaultClientID, DefaultAPIVersion)
+	client.SetTokenStore(store)
+	if err := client.LoginAuthorizationCode("consumerKey", "", "https://app.example.com/callback", "code", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	// The refresh token is revoked, which revokes the access token as well.
+	if len(revoked) != 1 || revoked[0] != "refreshToken" {
+		t.Fail()
+	}
+	if client.isLoggedIn() || client.GetRefreshToken() != "" {
+		t.Fail()
+	}
+	if session, _ := store.Load(); session != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,127 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CallOptions holds the options sent in the Sforce headers changing the behaviour of DML and query calls. Options
+// left unset (nil or zero) aren't sent, and salesforce uses its defaults.
+// Ref: https://developer.salesforce.com/docs/atlas.en-us.api_rest.meta/api_rest/headers.htm
+type CallOptions struct {
+	// AutoAssign runs the active assignment rules when creating or updating cases and leads (Sforce-Auto-Assign).
+	AutoAssign *bool
+	// DuplicateRuleAllowSave saves records detected as duplicates by duplicate rules with an alert action
+	// (Sforce-Duplicate-Rule-Header: allowSave).
+	DuplicateRuleAllowSave *bool
+	// DuplicateRuleRunAsCurrentUser applies the sharing rules of the current user when looking for duplicates
+	// (Sforce-Duplicate-Rule-Header: runAsCurrentUser).
+	DuplicateRuleRunAsCurrentUser *bool
+	// UpdateMru updates the list of most recently used items (Sforce-Mru: updateMru).
+	UpdateMru *bool
+	// ClientName identifies the client in the salesforce logs (Sforce-Call-Options: client).
+	ClientName string
+	// DefaultNamespace is the namespace prefix of the unqualified fields (Sforce-Call-Options: defaultNamespace).
+	DefaultNamespace string
+	// QueryBatchSize is the number of records returned per query page, from 200 to 2000
+	// (Sforce-Query-Options: batchSize).
+	QueryBatchSize int
+}
+
+// Bool returns a pointer to v, to set the boolean CallOptions.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// callOptionsKey is the context key of the call options overriding the options of the client.
+type callOptionsKey struct{}
+
+// SetCallOptions sets the options sent with every call of the client.
+func (client *Client) SetCallOptions(options CallOptions) {
+	client.callOptions = options
+}
+
+// WithCallOptions returns a context overriding the options of the client for the calls made with it, e.g. to bypass
+// duplicate rules for an import only:
+//
+//	ctx = simpleforce.WithCallOptions(ctx, simpleforce.CallOptions{DuplicateRuleAllowSave: simpleforce.Bool(true)})
+//	err := obj.CreateContext(ctx)
+//
+// The options set override those of the client one by one; the others are kept.
+func WithCallOptions(ctx context.Context, options CallOptions) context.Context {
+	if parent, ok := ctx.Value(callOptionsKey{}).(CallOptions); ok {
+		options = parent.merge(options)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, options)
+}
+
+// merge returns options with the options set in override replaced.
+func (options CallOptions) merge(override CallOptions) CallOptions {
+	if override.AutoAssign != nil {
+		options.AutoAssign = override.AutoAssign
+	}
+	if override.DuplicateRuleAllowSave != nil {
+		options.DuplicateRuleAllowSave = override.DuplicateRuleAllowSave
+	}
+	if override.DuplicateRuleRunAsCurrentUser != nil {
+		options.DuplicateRuleRunAsCurrentUser = override.DuplicateRuleRunAsCurrentUser
+	}
+	if override.UpdateMru != nil {
+		options.UpdateMru = override.UpdateMru
+	}
+	if override.ClientName != "" {
+		options.ClientName = override.ClientName
+	}
+	if override.DefaultNamespace != "" {
+		options.DefaultNamespace = override.DefaultNamespace
+	}
+	if override.QueryBatchSize != 0 {
+		options.QueryBatchSize = override.QueryBatchSize
+	}
+	return options
+}
+
+// applyCallOptions sets the Sforce headers of the request from the options of the client and its context. DML
+// options are only sent with record calls, and the batch size with queries.
+func (client *Client) applyCallOptions(req *http.Request) {
+	options := client.callOptions
+	if override, ok := req.Context().Value(callOptionsKey{}).(CallOptions); ok {
+		options = options.merge(override)
+	}
+	op, _ := req.Context().Value(operationKey{}).(operation)
+
+	if op.resource == ResourceSObject {
+		if options.AutoAssign != nil {
+			req.Header.Set("Sforce-Auto-Assign", strings.ToUpper(strconv.FormatBool(*options.AutoAssign)))
+		}
+		var duplicateRule []string
+		if options.DuplicateRuleAllowSave != nil {
+			duplicateRule = append(duplicateRule, "allowSave="+strconv.FormatBool(*options.DuplicateRuleAllowSave))
+		}
+		if options.DuplicateRuleRunAsCurrentUser != nil {
+			duplicateRule = append(duplicateRule, "runAsCurrentUser="+strconv.FormatBool(*options.DuplicateRuleRunAsCurrentUser))
+		}
+		if len(duplicateRule) > 0 {
+			req.Header.Set("Sforce-Duplicate-Rule-Header", strings.Join(duplicateRule, ", "))
+		}
+	}
+	if options.UpdateMru != nil && (op.resource == ResourceSObject || op.resource == ResourceQuery) {
+		req.Header.Set("Sforce-Mru", "updateMru="+strconv.FormatBool(*options.UpdateMru))
+	}
+	if options.QueryBatchSize != 0 && op.resource == ResourceQuery {
+		req.Header.Set("Sforce-Query-Options", "batchSize="+strconv.Itoa(options.QueryBatchSize))
+	}
+
+	var callOptions []string
+	if options.ClientName != "" {
+		callOptions = append(callOptions, "client="+options.ClientName)
+	}
+	if options.DefaultNamespace != "" {
+		callOptions = append(callOptions, "defaultNamespace="+options.DefaultNamespace)
+	}
+	if len(callOptions) > 0 {
+		req.Header.Set("Sforce-Call-Options", strings.Join(callOptions, ", "))
+	}
+}
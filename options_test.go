@@ -0,0 +1,59 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_SetCallOptions(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetCallOptions(CallOptions{
+		AutoAssign:             Bool(false),
+		DuplicateRuleAllowSave: Bool(false),
+		ClientName:             "importer",
+		QueryBatchSize:         500,
+	})
+	var headers http.Header
+	record := func(status int, body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			headers = req.Header
+			return httpmock.NewStringResponse(status, body), nil
+		}
+	}
+	httpmock.RegisterResponder("POST", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/sobjects/Lead/",
+		record(201, `{"id": "00Qxx", "success": true, "errors": []}`))
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/query?q=SELECT%20Id%20FROM%20Lead",
+		record(200, `{"totalSize": 0, "done": true, "records": []}`))
+
+	if err := client.SObject("Lead").Create(); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("Sforce-Auto-Assign") != "FALSE" || headers.Get("Sforce-Duplicate-Rule-Header") != "allowSave=false" ||
+		headers.Get("Sforce-Call-Options") != "client=importer" || headers.Get("Sforce-Query-Options") != "" {
+		t.Error(headers)
+	}
+
+	ctx := WithCallOptions(context.Background(), CallOptions{DuplicateRuleAllowSave: Bool(true), DuplicateRuleRunAsCurrentUser: Bool(true)})
+	ctx = WithCallOptions(ctx, CallOptions{UpdateMru: Bool(true)})
+	if err := client.SObject("Lead").CreateContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("Sforce-Auto-Assign") != "FALSE" || headers.Get("Sforce-Duplicate-Rule-Header") != "allowSave=true, runAsCurrentUser=true" ||
+		headers.Get("Sforce-Mru") != "updateMru=true" {
+		t.Error(headers)
+	}
+
+	if _, err := client.QueryContext(WithCallOptions(context.Background(), CallOptions{QueryBatchSize: 2000}), "SELECT Id FROM Lead"); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("Sforce-Query-Options") != "batchSize=2000" || headers.Get("Sforce-Auto-Assign") != "" ||
+		headers.Get("Sforce-Call-Options") != "client=importer" {
+		t.Error(headers)
+	}
+}
@@ -0,0 +1,149 @@
+package simpleforce
+
+import (
+	"context"
+)
+
+// QueryIterOptions configures a QueryIter.
+type QueryIterOptions struct {
+	// MaxRecords stops the iteration after that many records if positive. The pages past the limit aren't fetched.
+	MaxRecords int
+	// Prefetch fetches the next page in the background while the records of the current one are consumed.
+	Prefetch bool
+	// All includes the deleted and archived records, like QueryAll.
+	All bool
+}
+
+// QueryIter iterates over the records of a query, fetching the pages lazily as the records are consumed:
+//
+//	it := client.QueryIterContext(ctx, "SELECT Id, Name FROM Account", simpleforce.QueryIterOptions{Prefetch: true})
+//	for it.Next() {
+//		fmt.Println(it.Record().StringField("Name"))
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle the error
+//	}
+//
+// A QueryIter must not be used concurrently.
+type QueryIter struct {
+	ctx     context.Context
+	query   func(ctx context.Context, q string) (*QueryResult, error)
+	q       string
+	options QueryIterOptions
+
+	page   *QueryResult
+	index  int
+	count  int
+	record *SObject
+	err    error
+	// pending receives the next page when it is being prefetched.
+	pending chan queryIterPage
+}
+
+// queryIterPage is a page fetched in the background.
+type queryIterPage struct {
+	result *QueryResult
+	err    error
+}
+
+// NewQueryIter returns an iterator over the records of the query run with querier, e.g. a *Client or a fake.
+func NewQueryIter(ctx context.Context, querier Querier, q string, options QueryIterOptions) *QueryIter {
+	it := &QueryIter{ctx: ctx, query: querier.QueryContext, q: q, options: options}
+	if options.All {
+		it.query = querier.QueryAllContext
+	}
+	return it
+}
+
+// QueryIter returns an iterator over the records of the query.
+func (client *Client) QueryIter(q string, options QueryIterOptions) *QueryIter {
+	return client.QueryIterContext(context.Background(), q, options)
+}
+
+// QueryIterContext returns an iterator over the records of the query with the context. The iteration stops with the
+// context error once the context is done.
+func (client *Client) QueryIterContext(ctx context.Context, q string, options QueryIterOptions) *QueryIter {
+	return NewQueryIter(ctx, client, q, options)
+}
+
+// Next advances to the next record, fetching the next page if needed. It returns false when there are no more records,
+// the MaxRecords limit is reached, or an error occurred, which is then returned by Err.
+func (it *QueryIter) Next() bool {
+	it.record = nil
+	if it.err != nil || (it.options.MaxRecords > 0 && it.count >= it.options.MaxRecords) {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	for it.page == nil || it.index >= len(it.page.Records) {
+		if it.page != nil && (it.page.Done || it.page.NextRecordsURL == "") {
+			return false
+		}
+		page, err := it.fetch()
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page
+		it.index = 0
+		it.prefetch()
+	}
+
+	it.record = &it.page.Records[it.index]
+	it.index++
+	it.count++
+	return true
+}
+
+// Record returns the current record.
+func (it *QueryIter) Record() *SObject {
+	return it.record
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *QueryIter) Err() error {
+	return it.err
+}
+
+// TotalSize returns the number of records matched by the query, as reported with the first page. It is 0 until Next
+// has been called.
+func (it *QueryIter) TotalSize() int {
+	if it.page == nil {
+		return 0
+	}
+	return it.page.TotalSize
+}
+
+// fetch returns the first page of the query, or the page following the current one.
+func (it *QueryIter) fetch() (*QueryResult, error) {
+	if it.pending != nil {
+		page := <-it.pending
+		it.pending = nil
+		return page.result, page.err
+	}
+	q := it.q
+	if it.page != nil {
+		q = it.page.NextRecordsURL
+	}
+	return it.query(it.ctx, q)
+}
+
+// prefetch starts fetching the page following the current one, unless prefetching is disabled, there are no more pages
+// or the MaxRecords limit will be reached on the current page.
+func (it *QueryIter) prefetch() {
+	if !it.options.Prefetch || it.page.Done || it.page.NextRecordsURL == "" ||
+		(it.options.MaxRecords > 0 && it.count+len(it.page.Records) >= it.options.MaxRecords) {
+		return
+	}
+
+	// The channel is buffered so that the goroutine ends even if the iteration is abandoned.
+	pending := make(chan queryIterPage, 1)
+	go func(query func(ctx context.Context, q string) (*QueryResult, error), ctx context.Context, q string) {
+		result, err := query(ctx, q)
+		pending <- queryIterPage{result: result, err: err}
+	}(it.query, it.ctx, it.page.NextRecordsURL)
+	it.pending = pending
+}
@@ -0,0 +1,87 @@
+package simpleforce
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// registerPagedQueryMock serves the query "SELECT Id FROM Case" in pages of two records, and counts the pages served.
+func registerPagedQueryMock(c *Client, pages int) *int32 {
+	var served int32
+	baseURL := "https://na0-api.salesforce.com/services/data/v" + c.apiVersion
+	page := func(i int) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&served, 1)
+			body := fmt.Sprintf(`{"totalSize": %d, "done": %t, "records": [{"Id": "500xx%d0"}, {"Id": "500xx%d1"}]`, pages*2, i == pages-1, i, i)
+			if i < pages-1 {
+				body += fmt.Sprintf(`, "nextRecordsUrl": "/services/data/v%s/query/01gxx-%d"`, c.apiVersion, (i+1)*2)
+			}
+			return httpmock.NewStringResponse(200, body+"}"), nil
+		}
+	}
+	httpmock.RegisterResponder("GET", baseURL+"/query?q=SELECT%20Id%20FROM%20Case", page(0))
+	for i := 1; i < pages; i++ {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("%s/query/01gxx-%d", baseURL, i*2), page(i))
+	}
+	return &served
+}
+
+func TestClient_QueryIter(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	served := registerPagedQueryMock(client, 3)
+
+	for _, prefetch := range []bool{false, true} {
+		atomic.StoreInt32(served, 0)
+		it := client.QueryIter("SELECT Id FROM Case", QueryIterOptions{Prefetch: prefetch})
+		var ids []string
+		for it.Next() {
+			ids = append(ids, it.Record().ID())
+		}
+		if it.Err() != nil || len(ids) != 6 || ids[5] != "500xx21" || it.TotalSize() != 6 || atomic.LoadInt32(served) != 3 {
+			t.Error(prefetch, ids, it.Err())
+		}
+	}
+}
+
+func TestClient_QueryIter_maxRecords(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	served := registerPagedQueryMock(client, 3)
+
+	it := client.QueryIter("SELECT Id FROM Case", QueryIterOptions{MaxRecords: 3, Prefetch: true})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if it.Err() != nil || count != 3 || atomic.LoadInt32(served) != 2 {
+		t.Error(count, it.Err())
+	}
+}
+
+func TestClient_QueryIterContext_canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	registerPagedQueryMock(client, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.QueryIterContext(ctx, "SELECT Id FROM Case", QueryIterOptions{})
+	if !it.Next() {
+		t.Fatal(it.Err())
+	}
+	cancel()
+	if it.Next() || it.Err() != context.Canceled {
+		t.Error(it.Err())
+	}
+}
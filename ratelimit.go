@@ -0,0 +1,124 @@
+package simpleforce
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// SetRateLimit limits the rate of requests sent by the client, including logins and retries, to requestsPerSecond on
+// average with bursts of up to burst requests. Requests above the rate wait for their turn, or until their context is
+// done. A requestsPerSecond of 0 removes the limit, which is the default. It must be set before the client is used.
+func (client *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond <= 0 {
+		client.limiter = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	client.limiter = newRateLimiter(requestsPerSecond, burst)
+}
+
+// SetMaxConcurrentRequests limits the number of requests in flight, i.e. sent and whose response hasn't been read
+// entirely, to n. Salesforce allows 25 concurrent long-running requests per org. Requests above the limit wait for a
+// slot, or until their context is done. An n of 0 removes the limit, which is the default. It must be set before the
+// client is used.
+func (client *Client) SetMaxConcurrentRequests(n int) {
+	if n <= 0 {
+		client.inFlight = nil
+		return
+	}
+	client.inFlight = make(chan struct{}, n)
+}
+
+// acquire waits for the rate limiter and a free request slot. The returned function releases the slot.
+func (client *Client) acquire(ctx context.Context) (release func(), err error) {
+	if client.limiter != nil {
+		err = client.limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if client.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case client.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-client.inFlight })
+	}, nil
+}
+
+// releasingBody releases the request slot of a response once its body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the body and releases the request slot.
+func (body *releasingBody) Close() error {
+	defer body.release()
+	return body.ReadCloser.Close()
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second, holding up to burst tokens.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a full rateLimiter.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, waiting until one is available or ctx is done.
+func (limiter *rateLimiter) wait(ctx context.Context) error {
+	delay := limiter.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, which may not be available yet, and returns the time to wait until it is.
+func (limiter *rateLimiter) reserve() time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.rate)
+	limiter.last = now
+	limiter.tokens--
+	if limiter.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+}
+
+// cancel gives back a token reserved by a request which didn't wait for it.
+func (limiter *rateLimiter) cancel() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+1)
+}
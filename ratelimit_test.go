@@ -0,0 +1,88 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_SetMaxConcurrentRequests(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetMaxConcurrentRequests(3)
+	var inFlight, maxInFlight int32
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return httpmock.NewStringResponse(200, `{"totalSize": 0, "done": true, "records": []}`), nil
+	})
+
+	var wg sync.WaitGroup
+	var failures int32
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+				atomic.AddInt32(&failures, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if failures != 0 || maxInFlight != 3 {
+		t.Fail()
+	}
+
+	// All the slots are taken: the request waits until its context is done.
+	for i := 0; i < 3; i++ {
+		client.inFlight <- struct{}{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.QueryContext(ctx, "SELECT Id FROM Case"); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+}
+
+func TestClient_SetRateLimit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRateLimit(50, 2)
+	registerSessionCheckingQueryMock(client, "sessionId")
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2 requests are sent at once, then one every 20ms.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Error(elapsed)
+	}
+
+	client.SetRateLimit(0.1, 1)
+	client.limiter.reserve()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.QueryContext(ctx, "SELECT Id FROM Case"); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+}
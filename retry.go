@@ -0,0 +1,220 @@
+package simpleforce
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy configures how failed requests are retried by the client.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, which is doubled on every further retry. A random jitter of up to
+	// half of the delay is subtracted so that concurrent clients don't retry in lockstep.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. An attempt whose response asks with Retry-After to wait longer isn't
+	// retried, and its error is returned. If zero, the delays aren't capped but Retry-After is capped at a minute.
+	MaxDelay time.Duration
+	// Retryable decides if a failed attempt should be retried. resp and respData are nil if no response was received.
+	// DefaultRetryable is used if nil.
+	Retryable func(req *http.Request, resp *http.Response, respData []byte, err error) bool
+}
+
+// maxRetryAfter caps the delay asked with Retry-After when the policy has no MaxDelay.
+const maxRetryAfter = time.Minute
+
+// idempotentKey is the context key marking requests as safe to send again, such as logins sent as POST, or not, such
+// as executeAnonymous sent as GET.
+type idempotentKey struct{}
+
+// transientErrorCodes lists the salesforce error codes reported when the request was rejected before doing anything
+// and may succeed if sent again.
+var transientErrorCodes = map[string]bool{
+	"UNABLE_TO_LOCK_ROW":     true,
+	"REQUEST_LIMIT_EXCEEDED": true,
+	"SERVER_UNAVAILABLE":     true,
+}
+
+// DefaultRetryPolicy returns a policy making up to 3 attempts, 500ms then 1s apart.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+// SetRetryPolicy sets the policy to retry failed requests, including logins. Requests are not retried if policy is
+// nil, which is the default.
+func (client *Client) SetRetryPolicy(policy *RetryPolicy) {
+	client.retryPolicy = policy
+}
+
+// DefaultRetryable retries idempotent requests failing with a network error or a 5xx status, and any request failing
+// with a transient salesforce error code such as UNABLE_TO_LOCK_ROW, including SOAP faults such as
+// sf:SERVER_UNAVAILABLE. REQUEST_LIMIT_EXCEEDED isn't considered transient when the Sforce-Limit-Info header of the
+// response shows that the daily API request limit has been used up.
+func DefaultRetryable(req *http.Request, resp *http.Response, respData []byte, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if resp == nil {
+		return err != nil && isIdempotent(req)
+	}
+
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		for _, e := range apiError.Errors {
+			code := trimErrorCode(e.ErrorCode)
+			if code == "REQUEST_LIMIT_EXCEEDED" && dailyLimitReached(resp) {
+				continue
+			}
+			if transientErrorCodes[code] {
+				return true
+			}
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+// dailyLimitReached checks if the Sforce-Limit-Info header of the response reports that the daily API request limit
+// has been used up.
+func dailyLimitReached(resp *http.Response) bool {
+	used, max, ok := parseLimitInfo(resp.Header.Get("Sforce-Limit-Info"))
+	return ok && used >= max
+}
+
+// isIdempotent checks if the request can be sent again without side effects, as marked by withIdempotent or else
+// from its method. PATCH requests are considered idempotent as they are used by salesforce to update or upsert records
+// with the same values.
+func isIdempotent(req *http.Request) bool {
+	if idempotent, ok := req.Context().Value(idempotentKey{}).(bool); ok {
+		return idempotent
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// withIdempotent marks the requests made with the returned context as safe to send again or not, whatever their
+// method.
+func withIdempotent(ctx context.Context, idempotent bool) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, idempotent)
+}
+
+// shouldRetry checks if the failed attempt should be retried under the policy.
+func (policy *RetryPolicy) shouldRetry(attempt int, req *http.Request, resp *http.Response, respData []byte, err error) bool {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// The body has been consumed and can't be sent again.
+		return false
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	return retryable(req, resp, respData, err)
+}
+
+// delay returns the time to wait after the given attempt, honoring the Retry-After header of the response if any. It
+// returns false if Retry-After asks to wait longer than the maximum delay.
+func (policy *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+			limit := policy.MaxDelay
+			if limit <= 0 {
+				limit = maxRetryAfter
+			}
+			return retryAfter, retryAfter <= limit
+		}
+	}
+
+	delay := policy.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (policy.MaxDelay > 0 && delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay -= time.Duration(rand.Int63n(half))
+	}
+	return delay, true
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sendWithRetry sends the request, retrying failed attempts according to the retry policy of the client. Each attempt
+// waits for the rate limiter and a request slot, if set. A response with a 2xx status is returned as is and the caller
+// must close its body; otherwise the response data is read and returned along with the error parsed from it.
+func (client *Client) sendWithRetry(req *http.Request) (*http.Response, []byte, error) {
+	policy := client.retryPolicy
+	for attempt := 1; ; attempt++ {
+		// Transports may still send a request whose context is done, so don't start an attempt after cancellation.
+		if err := req.Context().Err(); err != nil {
+			return nil, nil, err
+		}
+		release, err := client.acquire(req.Context())
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, respData, err := client.sendOnce(req)
+		if err == nil {
+			// The request is in flight until its body has been read.
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, respData, err
+		}
+		release()
+		if !policy.shouldRetry(attempt, req, resp, respData, err) {
+			return resp, respData, err
+		}
+
+		delay, ok := policy.delay(attempt, resp)
+		if !ok {
+			client.log(LogLevelWarn, "request failed, Retry-After exceeds the maximum delay", "method", req.Method, "url", req.URL.String(), "attempt", attempt, "delay", delay, "error", err)
+			return resp, respData, err
+		}
+		client.log(LogLevelWarn, "request failed, retrying", "method", req.Method, "url", req.URL.String(), "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return resp, respData, err
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, respData, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
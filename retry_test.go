@@ -0,0 +1,282 @@
+package simpleforce
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+}
+
+func TestClient_Retry_query(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts, failures := 0, 2
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset by peer")
+		} else if attempts <= failures {
+			return httpmock.NewStringResponse(503, "Service Unavailable"), nil
+		}
+		return httpmock.NewStringResponse(200, `{"totalSize": 0, "done": true, "records": []}`), nil
+	})
+
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fail()
+	}
+
+	// Give up after MaxAttempts.
+	attempts, failures = 0, 10
+	if _, err := client.Query("SELECT Id FROM Case"); err == nil || attempts != 3 {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_create(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	status, body := 0, ""
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Case/"
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		data, _ := ioutil.ReadAll(req.Body)
+		if string(data) != `{"Subject":"Retried"}` {
+			return httpmock.NewStringResponse(400, `[{"message":"body not resent","errorCode":"INVALID_TYPE"}]`), nil
+		}
+		if attempts == 1 {
+			return httpmock.NewStringResponse(status, body), nil
+		}
+		return httpmock.NewStringResponse(201, `{"id": "500xx000000bYrEAAU", "success": true, "errors": []}`), nil
+	})
+
+	// A POST failing with a 5xx status may have created the record and must not be sent again.
+	status, body = 500, `[{"message":"An unexpected error occurred","errorCode":"UNKNOWN_EXCEPTION"}]`
+	obj := client.SObject("Case")
+	obj.Set("Subject", "Retried")
+	if obj.Create() == nil || attempts != 1 {
+		t.Fail()
+	}
+
+	// A row lock is reported before anything is done, so any request can be retried.
+	attempts = 0
+	status, body = 400, `[{"message":"unable to obtain exclusive access to this record","errorCode":"UNABLE_TO_LOCK_ROW"}]`
+	if err := obj.Create(); err != nil || obj.ID() != "500xx000000bYrEAAU" || attempts != 2 {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_requestLimit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	limitInfo := ""
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			resp := httpmock.NewStringResponse(403, `[{"message":"Request limit exceeded","errorCode":"REQUEST_LIMIT_EXCEEDED"}]`)
+			resp.Header.Set("Sforce-Limit-Info", limitInfo)
+			return resp, nil
+		}
+		return httpmock.NewStringResponse(200, `{"totalSize": 0, "done": true, "records": []}`), nil
+	})
+
+	// Too many concurrent requests: the daily limit isn't reached.
+	limitInfo = "api-usage=120/15000"
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil || attempts != 2 {
+		t.Error(attempts, err)
+	}
+
+	// The daily limit won't be reset by retrying.
+	attempts = 0
+	limitInfo = "api-usage=15000/15000"
+	if _, err := client.Query("SELECT Id FROM Case"); !errors.Is(err, ErrRequestLimitExceeded) || attempts != 1 {
+		t.Error(attempts, err)
+	}
+}
+
+func TestClient_Retry_executeAnonymous(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	status, body := 0, ""
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/tooling/executeAnonymous/"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return httpmock.NewStringResponse(status, body), nil
+		}
+		return httpmock.NewStringResponse(200, `{"line": -1, "column": -1, "compiled": true, "success": true}`), nil
+	})
+
+	// The Apex code may have run, so it isn't sent again although the request is a GET.
+	status, body = 503, "Service Unavailable"
+	if _, err := client.ExecuteAnonymous("insert new Account(Name = 'Acme');"); err == nil || attempts != 1 {
+		t.Fail()
+	}
+
+	// A row lock is reported before anything is done.
+	attempts = 0
+	status, body = 400, `[{"message":"unable to obtain exclusive access to this record","errorCode":"UNABLE_TO_LOCK_ROW"}]`
+	if result, err := client.ExecuteAnonymous("insert new Account(Name = 'Acme');"); err != nil || !result.Success || attempts != 2 {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_login(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	mockURL := "https://login.salesforce.com//services/Soap/u/" + client.apiVersion
+	login := func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return httpmock.NewStringResponse(503, "Service Unavailable"), nil
+		}
+		return httpmock.NewStringResponse(200, `<?xml version="1.0" encoding="utf-8" ?>
+			<env:Envelope><env:Body><env:loginResponse><env:result>
+				<env:serverUrl>https://na0-api.salesforce.com/services/Soap/c/2.5</env:serverUrl>
+				<env:sessionId>sessionId</env:sessionId>
+				<env:userId>userId</env:userId>
+			</env:result></env:loginResponse></env:Body></env:Envelope>`), nil
+	}
+	httpmock.RegisterResponder("POST", mockURL, login)
+
+	if err := client.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || client.GetSid() != "sessionId" {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour})
+	attempts := 0
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return httpmock.NewStringResponse(503, "Service Unavailable"), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.QueryContext(ctx, "SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Fail()
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if parseRetryAfter("120") != 2*time.Minute || parseRetryAfter("") != 0 || parseRetryAfter("soon") != 0 {
+		t.Fail()
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if delay := parseRetryAfter(date); delay < 59*time.Minute || delay > time.Hour {
+		t.Fail()
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if delay, ok := DefaultRetryPolicy().delay(1, resp); !ok || delay != 3*time.Second {
+		t.Fail()
+	}
+	// Waiting longer than the maximum delay gives up.
+	if _, ok := testRetryPolicy().delay(1, resp); ok {
+		t.Fail()
+	}
+	resp.Header.Set("Retry-After", "86400")
+	if _, ok := (&RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}).delay(1, resp); ok {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_retryAfter(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		resp := httpmock.NewStringResponse(503, "Service Unavailable")
+		resp.Header.Set("Retry-After", "3600")
+		return resp, nil
+	})
+
+	start := time.Now()
+	if _, err := client.Query("SELECT Id FROM Case"); err == nil {
+		t.Fail()
+	}
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Fail()
+	}
+}
+
+func TestClient_Retry_soapFault(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	client.SetRetryPolicy(testRetryPolicy())
+	attempts := 0
+	mockURL := "https://na0-api.salesforce.com/services/Soap/u/" + client.apiVersion
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return httpmock.NewStringResponse(500, `<?xml version="1.0" encoding="UTF-8"?>
+				<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"><soapenv:Body><soapenv:Fault>
+					<faultcode>sf:SERVER_UNAVAILABLE</faultcode>
+					<faultstring>SERVER_UNAVAILABLE: server temporarily unavailable</faultstring>
+				</soapenv:Fault></soapenv:Body></soapenv:Envelope>`), nil
+		}
+		return httpmock.NewStringResponse(200, `<?xml version="1.0" encoding="UTF-8"?>
+			<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">
+				<soapenv:Body><logoutResponse/></soapenv:Body>
+			</soapenv:Envelope>`), nil
+	})
+
+	// The SOAP logout isn't idempotent, so it is only retried for the transient fault code.
+	if err := client.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,222 @@
+package simpleforce
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sfdxDefaultClientID = "PlatformCLI"
+	sfdxKeyV1Length     = 32 // hex characters of a v1 key, used as is (utf8) for aes-256-gcm.
+	sfdxKeyV2Length     = 64 // hex characters of a v2 key, hex decoded for aes-256-gcm.
+	sfdxIVV1Length      = 12 // hex characters of a v1 IV, used as is (utf8).
+	sfdxIVV2Length      = 24 // hex characters of a v2 IV, hex decoded.
+)
+
+// sfdxDirs lists the Salesforce CLI state directories relative to the home directory, newest CLI first.
+var sfdxDirs = []string{".sf", ".sfdx"}
+
+// sfdxAuthInfo holds the fields used from a Salesforce CLI auth file (~/.sfdx/<username>.json).
+type sfdxAuthInfo struct {
+	AccessToken        string `json:"accessToken"`
+	RefreshToken       string `json:"refreshToken"`
+	InstanceURL        string `json:"instanceUrl"`
+	LoginURL           string `json:"loginUrl"`
+	Username           string `json:"username"`
+	OrgID              string `json:"orgId"`
+	ClientID           string `json:"clientId"`
+	ClientSecret       string `json:"clientSecret"`
+	InstanceAPIVersion string `json:"instanceApiVersion"`
+}
+
+// NewClientFromSFDX creates a logged-in client from the credentials of an org authorized with the Salesforce CLI
+// (sfdx or sf). aliasOrUsername is either an alias or the username of the org. The refresh token of the org, if any,
+// is kept so the session can be renewed with RefreshSession or SetAutoReauth.
+// Tokens encrypted by the CLI can only be read when the key is kept in the generic keychain file (~/.sfdx/key.json),
+// e.g. with SF_USE_GENERIC_UNIX_KEYCHAIN=true.
+func NewClientFromSFDX(aliasOrUsername string) (*Client, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	username := resolveSFDXAlias(home, aliasOrUsername)
+	auth, dir, err := readSFDXAuthInfo(home, username)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.AccessToken, err = decryptSFDXValue(dir, auth.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	auth.RefreshToken, err = decryptSFDXValue(dir, auth.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	auth.ClientSecret, err = decryptSFDXValue(dir, auth.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if auth.AccessToken == "" || auth.InstanceURL == "" {
+		return nil, errors.Errorf("no access token found for %s", username)
+	}
+
+	loginURL := auth.LoginURL
+	if loginURL == "" {
+		loginURL = DefaultURL
+	}
+	apiVersion := auth.InstanceAPIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	clientID := auth.ClientID
+	if clientID == "" {
+		clientID = sfdxDefaultClientID
+	}
+
+	client := NewClient(loginURL, DefaultClientID, apiVersion)
+	client.SetSidLoc(auth.AccessToken, parseHost(auth.InstanceURL))
+	client.user.Username = auth.Username
+	client.user.OrgID = auth.OrgID
+	client.oauth.consumerKey = clientID
+	client.oauth.consumerSecret = auth.ClientSecret
+	client.oauth.refreshToken = auth.RefreshToken
+	client.oauthSession = true
+	if auth.RefreshToken != "" {
+		client.setLogin(client.refreshSession)
+	}
+
+	client.log(LogLevelInfo, "user loaded from Salesforce CLI", "username", auth.Username)
+	return client, nil
+}
+
+// resolveSFDXAlias returns the username of an alias, or aliasOrUsername itself if no such alias is defined.
+func resolveSFDXAlias(home, aliasOrUsername string) string {
+	for _, dir := range sfdxDirs {
+		data, err := ioutil.ReadFile(filepath.Join(home, dir, "alias.json"))
+		if err != nil {
+			continue
+		}
+		var aliases struct {
+			Orgs map[string]string `json:"orgs"`
+		}
+		if json.Unmarshal(data, &aliases) != nil {
+			continue
+		}
+		if username, ok := aliases.Orgs[aliasOrUsername]; ok {
+			return username
+		}
+	}
+	return aliasOrUsername
+}
+
+// readSFDXAuthInfo reads the auth file of username, and returns the directory it was found in.
+func readSFDXAuthInfo(home, username string) (*sfdxAuthInfo, string, error) {
+	for _, dir := range sfdxDirs {
+		dir = filepath.Join(home, dir)
+		data, err := ioutil.ReadFile(filepath.Join(dir, username+".json"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, "", err
+		}
+
+		var auth sfdxAuthInfo
+		err = json.Unmarshal(data, &auth)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "unable to parse auth file of %s", username)
+		}
+		return &auth, dir, nil
+	}
+	return nil, "", errors.Errorf("no Salesforce CLI auth file found for %s", username)
+}
+
+// decryptSFDXValue decrypts a value encrypted by the Salesforce CLI ("<iv><ciphertext>:<tag>" in hex, aes-256-gcm)
+// with the key kept in key.json. Values which aren't encrypted are returned as is.
+func decryptSFDXValue(dir, value string) (string, error) {
+	sep := strings.LastIndex(value, ":")
+	if sep == -1 || !isHex(value[:sep]) || !isHex(value[sep+1:]) {
+		return value, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "key.json"))
+	if err != nil {
+		data, err = ioutil.ReadFile(filepath.Join(filepath.Dir(dir), ".sfdx", "key.json"))
+	}
+	if err != nil {
+		return "", errors.New("token is encrypted by the Salesforce CLI and key.json is not available; " +
+			"authorize the org with SF_USE_GENERIC_UNIX_KEYCHAIN=true")
+	}
+	var keyFile struct {
+		Key string `json:"key"`
+	}
+	err = json.Unmarshal(data, &keyFile)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse key.json")
+	}
+
+	var key, iv []byte
+	var ivLength int
+	switch len(keyFile.Key) {
+	case sfdxKeyV1Length:
+		key, ivLength = []byte(keyFile.Key), sfdxIVV1Length
+	case sfdxKeyV2Length:
+		key, err = hex.DecodeString(keyFile.Key)
+		if err != nil {
+			return "", err
+		}
+		ivLength = sfdxIVV2Length
+	default:
+		return "", errors.New("unsupported key in key.json")
+	}
+
+	payload := value[:sep]
+	if len(payload) < ivLength {
+		return "", errors.New("malformed encrypted token")
+	}
+	if ivLength == sfdxIVV1Length {
+		iv = []byte(payload[:ivLength])
+	} else if iv, err = hex.DecodeString(payload[:ivLength]); err != nil {
+		return "", err
+	}
+	ciphertext, err := hex.DecodeString(payload[ivLength:])
+	if err != nil {
+		return "", err
+	}
+	tag, err := hex.DecodeString(value[sep+1:])
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, iv, append(ciphertext, tag...), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to decrypt token")
+	}
+	return string(plain), nil
+}
+
+// isHex checks if s is a non-empty hex string.
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && len(s)%2 == 0
+}
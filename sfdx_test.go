@@ -0,0 +1,90 @@
+package simpleforce
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// useSFDXHome points the home directory to the Salesforce CLI fixtures, and returns a function to restore it.
+func useSFDXHome(t *testing.T) func() {
+	home, hadHome := os.LookupEnv("HOME")
+	if err := os.Setenv("HOME", "testdata/sfdx"); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if hadHome {
+			os.Setenv("HOME", home)
+		} else {
+			os.Unsetenv("HOME")
+		}
+	}
+}
+
+func TestNewClientFromSFDX_alias(t *testing.T) {
+	defer useSFDXHome(t)()
+
+	client, err := NewClientFromSFDX("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "00Dxx0000001gPL!plainAccessToken" || client.GetLoc() != "https://dev-ed.my.salesforce.com" {
+		t.Fail()
+	}
+	if client.apiVersion != "52.0" || client.GetRefreshToken() != "5Aep861plainRefreshToken" || !client.isLoggedIn() {
+		t.Fail()
+	}
+}
+
+func TestNewClientFromSFDX_encrypted(t *testing.T) {
+	defer useSFDXHome(t)()
+
+	// Alias defined by the sf CLI, auth file encrypted with the generic keychain key.
+	client, err := NewClientFromSFDX("ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "00Dxx0000001gPL!encryptedAccessToken" || client.GetRefreshToken() != "5Aep861encryptedRefreshToken" {
+		t.Fail()
+	}
+	if client.baseURL != "https://test.salesforce.com/" || client.apiVersion != DefaultAPIVersion {
+		t.Fail()
+	}
+}
+
+func TestNewClientFromSFDX_refresh(t *testing.T) {
+	defer useSFDXHome(t)()
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", mockTokenURL, func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+		if req.PostForm.Get("client_id") != "PlatformCLI" || req.PostForm.Get("refresh_token") != "5Aep861plainRefreshToken" {
+			return httpmock.NewStringResponse(400, `{"error":"invalid_grant","error_description":"expired access/refresh token"}`), nil
+		}
+		return httpmock.NewStringResponse(200, `{"access_token": "renewed", "instance_url": "https://dev-ed.my.salesforce.com"}`), nil
+	})
+
+	client, err := NewClientFromSFDX("dev@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.RefreshSession(); err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSid() != "renewed" {
+		t.Fail()
+	}
+}
+
+func TestNewClientFromSFDX_notFound(t *testing.T) {
+	defer useSFDXHome(t)()
+
+	if _, err := NewClientFromSFDX("unknown"); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,203 @@
+package sftest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/simpleforce/simpleforce"
+)
+
+// Fake is an in-memory implementation of simpleforce.API, for unit tests of code depending on the interfaces:
+//
+//	fake := sftest.NewFake()
+//	fake.Seed("Contact", map[string]interface{}{"LastName": "Doe"})
+//	err := syncContacts(ctx, fake) // func syncContacts(ctx context.Context, api simpleforce.API) error
+//
+// Results are returned as decoded by *simpleforce.Client, and errors as *simpleforce.APIError. Queries return all the
+// records in a single page. The records returned aren't associated with a client, so their methods calling salesforce,
+// such as Update, fail; use the methods of Fake instead.
+type Fake struct {
+	*store
+
+	// APIVersion is used in the URL attributes of the records.
+	APIVersion string
+
+	mu   sync.Mutex
+	apex []string
+}
+
+var _ simpleforce.API = (*Fake)(nil)
+
+// NewFake creates a Fake with an empty store.
+func NewFake() *Fake {
+	return &Fake{store: newStore(), APIVersion: simpleforce.DefaultAPIVersion}
+}
+
+// QueryContext runs an SOQL query of the subset supported by the package.
+func (fake *Fake) QueryContext(ctx context.Context, q string) (*simpleforce.QueryResult, error) {
+	return fake.queryContext(ctx, q, false)
+}
+
+// QueryAllContext runs an SOQL query including the deleted records.
+func (fake *Fake) QueryAllContext(ctx context.Context, q string) (*simpleforce.QueryResult, error) {
+	return fake.queryContext(ctx, q, true)
+}
+
+// queryContext runs an SOQL query, including the deleted records if all is set.
+func (fake *Fake) queryContext(ctx context.Context, q string, all bool) (*simpleforce.QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	records, err := fake.query(fake.APIVersion, q, all)
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	var result simpleforce.QueryResult
+	err = decode(map[string]interface{}{"totalSize": len(records), "done": true, "records": records}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetRecordContext retrieves the record of the type with the ID.
+func (fake *Fake) GetRecordContext(ctx context.Context, typeName, id string) (*simpleforce.SObject, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	record, err := fake.get(fake.APIVersion, typeName, id)
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	var obj simpleforce.SObject
+	err = decode(record, &obj)
+	if err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// CreateRecordContext creates a record of the type, and returns its ID.
+func (fake *Fake) CreateRecordContext(ctx context.Context, typeName string, fields map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	id, err := fake.create(typeName, fields)
+	if err != nil {
+		return "", apiError(err)
+	}
+	return id, nil
+}
+
+// UpdateRecordContext updates the fields of the record of the type with the ID.
+func (fake *Fake) UpdateRecordContext(ctx context.Context, typeName, id string, fields map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return apiError(fake.update(typeName, id, fields))
+}
+
+// UpsertRecordContext creates or updates the record of the type whose external ID field matches the value set in
+// fields.
+func (fake *Fake) UpsertRecordContext(ctx context.Context, typeName, externalIDField string, fields map[string]interface{}) (*simpleforce.SaveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Like the client, only string external IDs are accepted.
+	value, _ := fields[externalIDField].(string)
+	if value == "" {
+		return nil, errors.New("external ID field not set")
+	}
+	id, created, err := fake.upsert(typeName, externalIDField, value, fields)
+	if err != nil {
+		return nil, apiError(err)
+	}
+	return &simpleforce.SaveResult{ID: id, Success: true, Created: created}, nil
+}
+
+// DeleteRecordContext deletes the record of the type with the ID.
+func (fake *Fake) DeleteRecordContext(ctx context.Context, typeName, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return apiError(fake.delete(typeName, id))
+}
+
+// DescribeGlobalContext lists the objects of the store.
+func (fake *Fake) DescribeGlobalContext(ctx context.Context) (*simpleforce.SObjectMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var meta simpleforce.SObjectMeta
+	err := decode(fake.describeGlobal(), &meta)
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// DescribeSObjectContext describes the object of the type.
+func (fake *Fake) DescribeSObjectContext(ctx context.Context, typeName string) (*simpleforce.SObjectMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	describe, err := fake.describe(typeName)
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	var meta simpleforce.SObjectMeta
+	err = decode(describe, &meta)
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// ToolingQueryContext runs an SOQL query on the store, like QueryContext. Tooling objects, such as ApexClass, are
+// seeded like the other objects.
+func (fake *Fake) ToolingQueryContext(ctx context.Context, q string) (*simpleforce.QueryResult, error) {
+	return fake.QueryContext(ctx, q)
+}
+
+// ExecuteAnonymousContext records the Apex code, which is returned by ExecutedApex, and reports it as successfully
+// compiled and executed.
+func (fake *Fake) ExecuteAnonymousContext(ctx context.Context, apexBody string) (*simpleforce.ExecuteAnonymousResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.apex = append(fake.apex, apexBody)
+	return &simpleforce.ExecuteAnonymousResult{Line: -1, Column: -1, Compiled: true, Success: true}, nil
+}
+
+// ExecutedApex returns the Apex code executed with ExecuteAnonymousContext, in order.
+func (fake *Fake) ExecutedApex() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return append([]string(nil), fake.apex...)
+}
+
+// apiError converts the errors of the store into the *simpleforce.APIError the client would return.
+func apiError(err error) error {
+	storeErr, ok := err.(*storeError)
+	if !ok {
+		return err
+	}
+	body, _ := json.Marshal([]interface{}{storeErr.entry()})
+	return simpleforce.ParseSalesforceError(storeErr.statusCode, body)
+}
+
+// decode converts v into out through JSON, as the client decodes the responses.
+func decode(v interface{}, out interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,103 @@
+package sftest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/simpleforce/simpleforce"
+	"github.com/simpleforce/simpleforce/sftest"
+)
+
+// renameContacts is business logic depending on the interfaces rather than on *simpleforce.Client.
+func renameContacts(ctx context.Context, api simpleforce.API, from, to string) (int, error) {
+	result, err := api.QueryContext(ctx, "SELECT Id FROM Contact WHERE LastName = '"+from+"'")
+	if err != nil {
+		return 0, err
+	}
+	for _, record := range result.Records {
+		err = api.UpdateRecordContext(ctx, "Contact", record.ID(), map[string]interface{}{"LastName": to})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(result.Records), nil
+}
+
+func TestFake(t *testing.T) {
+	fake := sftest.NewFake()
+	if err := fake.LoadFixtures("testdata/fixtures.json"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	n, err := renameContacts(ctx, fake, "Doe", "Smith")
+	if err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+	if records := fake.Records("Contact"); records[0]["LastName"] != "Smith" || records[2]["LastName"] != "Roe" {
+		t.Error(records)
+	}
+
+	id, err := fake.CreateRecordContext(ctx, "Account", map[string]interface{}{"Name": "Globex", "Code__c": "GLX"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := fake.GetRecordContext(ctx, "Account", id)
+	if err != nil || obj.StringField("Name") != "Globex" || obj.Type() != "Account" {
+		t.Error(obj, err)
+	}
+	result, err := fake.UpsertRecordContext(ctx, "Account", "Code__c", map[string]interface{}{"Code__c": "GLX", "Name": "Globex Corp"})
+	if err != nil || result.Created || result.ID != id {
+		t.Error(result, err)
+	}
+
+	if err := fake.DeleteRecordContext(ctx, "Account", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.GetRecordContext(ctx, "Account", id); !errors.Is(err, simpleforce.ErrNotFound) {
+		t.Error(err)
+	}
+	if all, err := fake.QueryAllContext(ctx, "SELECT Id FROM Account WHERE IsDeleted = true"); err != nil || all.TotalSize != 1 {
+		t.Error(all, err)
+	}
+	if _, err := fake.QueryContext(ctx, "SELECT COUNT() FROM Account"); !errors.Is(err, simpleforce.ErrMalformedQuery) {
+		t.Error(err)
+	}
+
+	meta, err := fake.DescribeSObjectContext(ctx, "Contact")
+	if err != nil || (*meta)["name"] != "Contact" {
+		t.Error(meta, err)
+	}
+
+	if _, err := fake.ExecuteAnonymousContext(ctx, "System.debug('test');"); err != nil {
+		t.Fatal(err)
+	}
+	if apex := fake.ExecutedApex(); len(apex) != 1 {
+		t.Error(apex)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := fake.QueryContext(canceled, "SELECT Id FROM Contact"); err != context.Canceled {
+		t.Error(err)
+	}
+}
+
+func TestFake_UpsertRecordContext(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	// The fake must agree with the client on which external IDs are accepted.
+	for _, value := range []interface{}{"ext-1", "ext-1", 1e6, "", nil} {
+		fields := map[string]interface{}{"LastName": "Doe", "External_Id__c": value}
+		clientResult, clientErr := newClient(t, server).UpsertRecordContext(ctx, "Contact", "External_Id__c", fields)
+		fakeResult, fakeErr := sftest.NewFake().UpsertRecordContext(ctx, "Contact", "External_Id__c", fields)
+		if (clientErr == nil) != (fakeErr == nil) {
+			t.Error(value, clientErr, fakeErr)
+		} else if clientErr == nil && (!clientResult.Success || !fakeResult.Success) {
+			t.Error(value, clientResult, fakeResult)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+// Package sftest provides an in-process fake salesforce server, emulating the REST calls made by simpleforce on an
+// in-memory store, so that code using simpleforce can be tested without a salesforce org or mocking every URL:
+//
+//	server := sftest.NewServer()
+//	defer server.Close()
+//	server.Seed("Contact", map[string]interface{}{"LastName": "Doe", "Email": "jane.doe@example.com"})
+//
+//	client := simpleforce.NewClient(server.URL, simpleforce.DefaultClientID, simpleforce.DefaultAPIVersion)
+//	err := client.LoginPassword("user@example.com", "password", "")
+//	result, err := client.Query("SELECT Id, LastName FROM Contact WHERE Email = 'jane.doe@example.com'")
+//
+// The server supports the SOAP login and logout, describeGlobal, describe, record creation, retrieval, update,
+// upsert by external ID and deletion, and paginated queries. Deleted records are kept in a recycle bin, returned by
+// queryAll with IsDeleted set. Queries are limited to the following subset of SOQL:
+//
+//	SELECT field, ... FROM Type [WHERE field = value [AND field = value ...]] [LIMIT n]
+//
+// Errors are reported with the status codes and error codes of salesforce, e.g. NOT_FOUND or INVALID_SESSION_ID.
+//
+// Fake implements simpleforce.API on the same in-memory store without HTTP, for unit tests of code depending on the
+// interfaces rather than on *simpleforce.Client.
+package sftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// OrgID is the ID of the organization returned by logins.
+	OrgID = "00D000000000001AAA"
+	// UserID is the ID of the user returned by logins.
+	UserID = "005000000000001AAA"
+
+	// DefaultPageSize is the default number of records returned per query page.
+	DefaultPageSize = 2000
+)
+
+var (
+	dataPathPattern  = regexp.MustCompile(`^/+services/data/v(\d+\.\d+)/(.*)$`)
+	soapPathPattern  = regexp.MustCompile(`^/+services/Soap/u/(\d+\.\d+)`)
+	usernamePattern  = regexp.MustCompile(`<(?:\w+:)?username>([^<]*)<`)
+	passwordPattern  = regexp.MustCompile(`<(?:\w+:)?password>([^<]*)<`)
+	sessionIDPattern = regexp.MustCompile(`<(?:\w+:)?sessionId>([^<]*)<`)
+	batchSizePattern = regexp.MustCompile(`batchSize=(\d+)`)
+)
+
+// Server is a fake salesforce server. Its URL is used as the login URL of the clients.
+type Server struct {
+	*httptest.Server
+	*store
+
+	mu       sync.Mutex
+	username string
+	password string
+	sessions map[string]bool
+	// cursors holds the paginated queries not read to the end, by query locator.
+	cursors  map[string]*cursor
+	pageSize int
+	serial   int
+}
+
+// cursor holds the records of a paginated query, and the endpoint of the query, query or queryAll, which serves the
+// next pages.
+type cursor struct {
+	resource string
+	records  []map[string]interface{}
+	pageSize int
+}
+
+// NewServer starts a server with an empty store. It must be closed with Close.
+func NewServer() *Server {
+	server := &Server{
+		store:    newStore(),
+		sessions: make(map[string]bool),
+		cursors:  make(map[string]*cursor),
+		pageSize: DefaultPageSize,
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// SetCredentials restricts the logins to the username and password, which includes the security token if any. Any
+// credentials are accepted otherwise.
+func (server *Server) SetCredentials(username, password string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.username = username
+	server.password = password
+}
+
+// SetPageSize sets the number of records returned per query page, unless the query sets its batch size with the
+// Sforce-Query-Options header.
+func (server *Server) SetPageSize(n int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.pageSize = n
+}
+
+// ExpireSessions invalidates the sessions, so that the requests fail with INVALID_SESSION_ID until the clients log in
+// again.
+func (server *Server) ExpireSessions() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.sessions = make(map[string]bool)
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if match := soapPathPattern.FindStringSubmatch(req.URL.Path); match != nil && req.Method == http.MethodPost {
+		server.serveSOAP(w, req, match[1])
+		return
+	}
+
+	match := dataPathPattern.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	if !server.sessions[strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")] {
+		writeError(w, &storeError{statusCode: http.StatusUnauthorized, errorCode: "INVALID_SESSION_ID", message: "Session expired or invalid"})
+		return
+	}
+
+	version := match[1]
+	parts := strings.Split(strings.TrimSuffix(match[2], "/"), "/")
+	switch {
+	case (parts[0] == "query" || parts[0] == "queryAll") && len(parts) == 1 && req.Method == http.MethodGet:
+		server.serveQuery(w, req, version, parts[0])
+	case (parts[0] == "query" || parts[0] == "queryAll") && len(parts) == 2 && req.Method == http.MethodGet:
+		server.serveQueryMore(w, version, parts[0], parts[1])
+	case parts[0] == "sobjects" && len(parts) == 1 && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, server.describeGlobal())
+	case parts[0] == "sobjects" && len(parts) == 2 && req.Method == http.MethodPost:
+		server.serveCreate(w, req, parts[1])
+	case parts[0] == "sobjects" && len(parts) == 3 && parts[2] == "describe" && req.Method == http.MethodGet:
+		describe, err := server.describe(parts[1])
+		writeResult(w, http.StatusOK, describe, err)
+	case parts[0] == "sobjects" && len(parts) == 3:
+		server.serveRecord(w, req, version, parts[1], parts[2])
+	case parts[0] == "sobjects" && len(parts) == 4 && req.Method == http.MethodGet:
+		record, err := server.getByExternalID(version, parts[1], parts[2], parts[3])
+		writeResult(w, http.StatusOK, record, err)
+	case parts[0] == "sobjects" && len(parts) == 4 && req.Method == http.MethodPatch:
+		server.serveUpsert(w, req, parts[1], parts[2], parts[3])
+	default:
+		writeError(w, errNotFound)
+	}
+}
+
+// serveSOAP answers the SOAP login and logout calls.
+func (server *Server) serveSOAP(w http.ResponseWriter, req *http.Request, version string) {
+	body, _ := ioutil.ReadAll(req.Body)
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+
+	if strings.Trim(req.Header.Get("SOAPAction"), `"`) == "logout" {
+		if match := sessionIDPattern.FindSubmatch(body); match != nil {
+			delete(server.sessions, string(match[1]))
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns="urn:partner.soap.sforce.com"><soapenv:Body><logoutResponse/></soapenv:Body></soapenv:Envelope>`)
+		return
+	}
+
+	var username, password string
+	if match := usernamePattern.FindSubmatch(body); match != nil {
+		username = html.UnescapeString(string(match[1]))
+	}
+	if match := passwordPattern.FindSubmatch(body); match != nil {
+		password = html.UnescapeString(string(match[1]))
+	}
+	if server.username != "" && (username != server.username || password != server.password) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:sf="urn:fault.partner.soap.sforce.com"><soapenv:Body><soapenv:Fault><faultcode>sf:INVALID_LOGIN</faultcode><faultstring>INVALID_LOGIN: Invalid username, password, security token; or user locked out.</faultstring></soapenv:Fault></soapenv:Body></soapenv:Envelope>`)
+		return
+	}
+
+	server.serial++
+	sessionID := fmt.Sprintf("%s!sftest.session.%d", OrgID[:15], server.serial)
+	server.sessions[sessionID] = true
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns="urn:partner.soap.sforce.com">
+<soapenv:Body><loginResponse><result>
+<serverUrl>%s/services/Soap/u/%s/%s</serverUrl>
+<sessionId>%s</sessionId>
+<userId>%s</userId>
+<sandbox>true</sandbox>
+<userInfo>
+<organizationId>%s</organizationId>
+<userEmail>%s</userEmail>
+<userFullName>sftest</userFullName>
+<userName>%s</userName>
+<userType>Standard</userType>
+</userInfo>
+</result></loginResponse></soapenv:Body></soapenv:Envelope>`,
+		server.URL, version, OrgID[:15], sessionID, UserID, OrgID, html.EscapeString(username), html.EscapeString(username))
+}
+
+// serveQuery answers the first page of a query, which includes the deleted records for the queryAll resource.
+func (server *Server) serveQuery(w http.ResponseWriter, req *http.Request, version, resource string) {
+	records, err := server.query(version, req.URL.Query().Get("q"), resource == "queryAll")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	pageSize := server.pageSize
+	if match := batchSizePattern.FindStringSubmatch(req.Header.Get("Sforce-Query-Options")); match != nil {
+		pageSize, _ = strconv.Atoi(match[1])
+	}
+	server.serial++
+	locator := fmt.Sprintf("01g%012dAAA", server.serial)
+	server.cursors[locator] = &cursor{resource: resource, records: records, pageSize: pageSize}
+	server.writePage(w, version, locator, 0)
+}
+
+// serveQueryMore answers the next pages of a query, identified by the query locator and the offset of the page, from
+// the endpoint of the query.
+func (server *Server) serveQueryMore(w http.ResponseWriter, version, resource, next string) {
+	invalid := &storeError{statusCode: http.StatusBadRequest, errorCode: "INVALID_QUERY_LOCATOR", message: "invalid query locator"}
+	i := strings.LastIndex(next, "-")
+	if i == -1 {
+		writeError(w, invalid)
+		return
+	}
+	locator := next[:i]
+	offset, err := strconv.Atoi(next[i+1:])
+	if c, ok := server.cursors[locator]; !ok || c.resource != resource || err != nil {
+		writeError(w, invalid)
+		return
+	}
+	server.writePage(w, version, locator, offset)
+}
+
+// writePage writes the page of the query records starting at offset. The cursor is dropped with its last page.
+func (server *Server) writePage(w http.ResponseWriter, version, locator string, offset int) {
+	c := server.cursors[locator]
+	end := len(c.records)
+	if offset > end {
+		offset = end
+	}
+	if c.pageSize > 0 && offset+c.pageSize < end {
+		end = offset + c.pageSize
+	}
+
+	result := map[string]interface{}{
+		"totalSize": len(c.records),
+		"done":      end == len(c.records),
+		"records":   c.records[offset:end],
+	}
+	if end == len(c.records) {
+		delete(server.cursors, locator)
+	} else {
+		result["nextRecordsUrl"] = fmt.Sprintf("/services/data/v%s/%s/%s-%d", version, c.resource, locator, end)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// serveCreate creates a record.
+func (server *Server) serveCreate(w http.ResponseWriter, req *http.Request, typeName string) {
+	fields, ok := readRecord(w, req)
+	if !ok {
+		return
+	}
+	id, err := server.create(typeName, fields)
+	writeResult(w, http.StatusCreated, map[string]interface{}{"id": id, "success": true, "errors": []interface{}{}}, err)
+}
+
+// serveRecord retrieves, updates or deletes a record identified by its ID.
+func (server *Server) serveRecord(w http.ResponseWriter, req *http.Request, version, typeName, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		record, err := server.get(version, typeName, id)
+		writeResult(w, http.StatusOK, record, err)
+	case http.MethodPatch:
+		fields, ok := readRecord(w, req)
+		if !ok {
+			return
+		}
+		writeResult(w, http.StatusNoContent, nil, server.update(typeName, id, fields))
+	case http.MethodDelete:
+		writeResult(w, http.StatusNoContent, nil, server.delete(typeName, id))
+	default:
+		writeError(w, &storeError{statusCode: http.StatusMethodNotAllowed, errorCode: "METHOD_NOT_ALLOWED",
+			message: "HTTP Method '" + req.Method + "' not allowed"})
+	}
+}
+
+// serveUpsert creates or updates a record identified by an external ID.
+func (server *Server) serveUpsert(w http.ResponseWriter, req *http.Request, typeName, field, value string) {
+	fields, ok := readRecord(w, req)
+	if !ok {
+		return
+	}
+	id, created, err := server.upsert(typeName, field, value, fields)
+	statusCode := http.StatusOK
+	if created {
+		statusCode = http.StatusCreated
+	}
+	writeResult(w, statusCode, map[string]interface{}{"id": id, "success": true, "errors": []interface{}{}, "created": created}, err)
+}
+
+// readRecord decodes the fields of the record sent in the request body, and writes a JSON_PARSER_ERROR otherwise.
+func readRecord(w http.ResponseWriter, req *http.Request) (map[string]interface{}, bool) {
+	var fields map[string]interface{}
+	err := json.NewDecoder(req.Body).Decode(&fields)
+	if err != nil {
+		writeError(w, &storeError{statusCode: http.StatusBadRequest, errorCode: "JSON_PARSER_ERROR", message: err.Error()})
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeResult writes v, or the error if any. Nothing is written for a 204 status.
+func writeResult(w http.ResponseWriter, statusCode int, v interface{}, err error) {
+	switch {
+	case err != nil:
+		writeError(w, err)
+	case statusCode == http.StatusNoContent:
+		w.WriteHeader(statusCode)
+	default:
+		writeJSON(w, statusCode, v)
+	}
+}
+
+// writeJSON writes the JSON encoding of v.
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the REST API.
+func writeError(w http.ResponseWriter, err error) {
+	storeErr, ok := err.(*storeError)
+	if !ok {
+		storeErr = &storeError{statusCode: http.StatusBadRequest, errorCode: "JSON_PARSER_ERROR", message: err.Error()}
+	}
+	writeJSON(w, storeErr.statusCode, []interface{}{storeErr.entry()})
+}
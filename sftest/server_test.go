@@ -0,0 +1,222 @@
+package sftest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/simpleforce/simpleforce"
+	"github.com/simpleforce/simpleforce/sftest"
+)
+
+func newClient(t *testing.T, server *sftest.Server) *simpleforce.Client {
+	client := simpleforce.NewClient(server.URL, simpleforce.DefaultClientID, simpleforce.DefaultAPIVersion)
+	if err := client.LoginPassword("user@example.com", "password", ""); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestServer_Query(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	if err := server.LoadFixtures("testdata/fixtures.json"); err != nil {
+		t.Fatal(err)
+	}
+	client := newClient(t, server)
+
+	result, err := client.Query("SELECT Id, FirstName, email FROM Contact WHERE LastName = 'doe' AND AccountId = '001000000000100AAA'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalSize != 2 || !result.Done || result.Records[0].StringField("FirstName") != "Jane" ||
+		result.Records[1].StringField("Email") != "john.doe@example.com" || result.Records[0].Type() != "Contact" {
+		t.Error(result)
+	}
+
+	result, err = client.Query("SELECT Id FROM Contact WHERE HasOptedOutOfEmail = true LIMIT 1")
+	if err != nil || result.TotalSize != 1 {
+		t.Error(result, err)
+	}
+	result, err = client.Query("SELECT Name FROM Account WHERE NumberOfEmployees = 250")
+	if err != nil || result.Records[0].StringField("Name") != "Acme" {
+		t.Error(result, err)
+	}
+
+	if _, err = client.Query("SELECT Id FROM Contact WHERE LastName LIKE 'D%'"); !errors.Is(err, simpleforce.ErrMalformedQuery) {
+		t.Error(err)
+	}
+	if _, err = client.Query("SELECT Id FROM Widget__c"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestServer_Query_pagination(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	for i := 0; i < 5; i++ {
+		server.Seed("Lead", map[string]interface{}{"LastName": "Lead", "Rating": i})
+	}
+	server.SetPageSize(2)
+	client := newClient(t, server)
+
+	var ratings []float64
+	result, err := client.Query("SELECT Id, Rating FROM Lead")
+	for err == nil {
+		for _, record := range result.Records {
+			ratings = append(ratings, record.InterfaceField("Rating").(float64))
+		}
+		if result.Done {
+			break
+		}
+		result, err = client.Query(result.NextRecordsURL)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 5 || ratings[4] != 4 || result.TotalSize != 5 {
+		t.Error(ratings)
+	}
+}
+
+func TestServer_SObject(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	client := newClient(t, server)
+
+	contact := client.SObject("Contact")
+	contact.Set("LastName", "Doe")
+	contact.Set("External_Id__c", "ext-1")
+	if err := contact.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := client.SObject("Contact")
+	if err := got.Get(contact.ID()); err != nil || got.StringField("LastName") != "Doe" {
+		t.Fatal(got, err)
+	}
+
+	got.Set("FirstName", "Jane")
+	if err := got.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := client.SObject("Contact")
+	upsert.Set("External_Id__c", "ext-1")
+	upsert.Set("Email", "jane.doe@example.com")
+	result, err := upsert.UpsertWithResult("External_Id__c")
+	if err != nil || result.Created || result.ID != contact.ID() {
+		t.Error(result, err)
+	}
+	upsert.Set("External_Id__c", "ext-2")
+	result, err = upsert.UpsertWithResult("External_Id__c")
+	if err != nil || !result.Created {
+		t.Error(result, err)
+	}
+
+	records := server.Records("Contact")
+	if len(records) != 2 || records[0]["FirstName"] != "Jane" || records[0]["Email"] != "jane.doe@example.com" {
+		t.Error(records)
+	}
+
+	if err := got.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SObject("Contact").Get(contact.ID()); !errors.Is(err, simpleforce.ErrNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestServer_Describe(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	server.Seed("Account", map[string]interface{}{"Name": "Acme", "IsActive__c": true})
+	server.SetDescribe("Widget__c", map[string]interface{}{
+		"name":   "Widget__c",
+		"fields": []map[string]interface{}{{"name": "Id"}, {"name": "Size__c", "type": "double"}},
+	})
+	client := newClient(t, server)
+
+	global, err := client.DescribeGlobal()
+	if err != nil || len((*global)["sobjects"].([]interface{})) != 2 {
+		t.Fatal(global, err)
+	}
+	meta, err := client.SObject("Account").Describe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := (*meta)["fields"].([]interface{})
+	if len(fields) != 3 || fields[1].(map[string]interface{})["type"] != "boolean" {
+		t.Error(fields)
+	}
+
+	widget := client.SObject("Widget__c")
+	widget.Set("Color__c", "red")
+	if err := widget.Create(); !errors.Is(err, simpleforce.ErrInvalidField) {
+		t.Error(err)
+	}
+	if _, err := client.Query("SELECT Id, Size__c FROM Widget__c"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServer_sessions(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	server.Seed("Case")
+	server.SetCredentials("user@example.com", "password")
+
+	client := simpleforce.NewClient(server.URL, simpleforce.DefaultClientID, simpleforce.DefaultAPIVersion)
+	if err := client.LoginPassword("user@example.com", "wrong", ""); !errors.Is(err, simpleforce.ErrAuthentication) {
+		t.Error(err)
+	}
+	client = newClient(t, server)
+	if user, err := client.CurrentUser(); err != nil || user.OrgID != sftest.OrgID {
+		t.Error(user, err)
+	}
+
+	server.ExpireSessions()
+	if _, err := client.Query("SELECT Id FROM Case"); !errors.Is(err, simpleforce.ErrInvalidSession) {
+		t.Error(err)
+	}
+	client.SetAutoReauth(true)
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServer_QueryAll(t *testing.T) {
+	server := sftest.NewServer()
+	defer server.Close()
+	ids := server.Seed("Case", map[string]interface{}{"Subject": "Kept"}, map[string]interface{}{"Subject": "Deleted"})
+	client := newClient(t, server)
+
+	if err := client.SObject("Case").Delete(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SObject("Case").Get(ids[1]); !errors.Is(err, simpleforce.ErrEntityDeleted) {
+		t.Error(err)
+	}
+	result, err := client.Query("SELECT Id FROM Case")
+	if err != nil || result.TotalSize != 1 {
+		t.Error(result, err)
+	}
+	result, err = client.QueryAll("SELECT Id, Subject, IsDeleted FROM Case WHERE IsDeleted = true")
+	if err != nil || result.TotalSize != 1 || result.Records[0].StringField("Subject") != "Deleted" {
+		t.Error(result, err)
+	}
+
+	// The next pages are served by the queryAll endpoint.
+	server.SetPageSize(1)
+	result, err = client.QueryAll("SELECT Id FROM Case")
+	if err != nil || result.Done || !strings.Contains(result.NextRecordsURL, "/queryAll/") {
+		t.Fatal(result, err)
+	}
+	if _, err := client.Query(strings.Replace(result.NextRecordsURL, "/queryAll/", "/query/", 1)); err == nil {
+		t.Error("queryAll locator served by the query endpoint")
+	}
+	result, err = client.QueryAll(result.NextRecordsURL)
+	if err != nil || !result.Done || len(result.Records) != 1 {
+		t.Error(result, err)
+	}
+}
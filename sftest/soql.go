@@ -0,0 +1,98 @@
+package sftest
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// query is a parsed SOQL query of the subset understood by the server:
+//
+//	SELECT field, ... FROM Type [WHERE field = value [AND field = value ...]] [LIMIT n]
+type query struct {
+	fields     []string
+	sObject    string
+	conditions []condition
+	limit      int
+}
+
+// condition is an equality between a field and a literal value.
+type condition struct {
+	field string
+	value interface{}
+}
+
+var (
+	queryPattern     = regexp.MustCompile(`(?is)^\s*SELECT\s+(.+?)\s+FROM\s+(\w+)(?:\s+WHERE\s+(.+?))?(?:\s+LIMIT\s+(\d+))?\s*$`)
+	fieldPattern     = regexp.MustCompile(`^\w+$`)
+	conditionPattern = regexp.MustCompile(`(?is)^\s*(\w+)\s*=\s*('(?:[^'\\]|\\.)*'|[^\s']+)\s*(?:AND\s+|$)`)
+)
+
+// parseQuery parses q, and returns an error for the queries outside of the supported subset.
+func parseQuery(q string) (*query, error) {
+	match := queryPattern.FindStringSubmatch(q)
+	if match == nil {
+		return nil, errors.Errorf("unsupported query: %s", q)
+	}
+
+	parsed := &query{sObject: match[2], limit: -1}
+	for _, field := range strings.Split(match[1], ",") {
+		field = strings.TrimSpace(field)
+		if !fieldPattern.MatchString(field) {
+			return nil, errors.Errorf("unsupported field: %s", field)
+		}
+		parsed.fields = append(parsed.fields, field)
+	}
+
+	where := match[3]
+	for where != "" {
+		cond := conditionPattern.FindStringSubmatch(where)
+		if cond == nil {
+			return nil, errors.Errorf("unsupported condition: %s", where)
+		}
+		value, err := parseLiteral(cond[2])
+		if err != nil {
+			return nil, err
+		}
+		parsed.conditions = append(parsed.conditions, condition{field: cond[1], value: value})
+		where = where[len(cond[0]):]
+	}
+
+	if match[4] != "" {
+		parsed.limit, _ = strconv.Atoi(match[4])
+	}
+	return parsed, nil
+}
+
+// parseLiteral parses a quoted string, a number, a boolean or null into the value JSON decoding would give.
+func parseLiteral(literal string) (interface{}, error) {
+	if strings.HasPrefix(literal, "'") {
+		var value strings.Builder
+		escaped := false
+		for _, r := range literal[1 : len(literal)-1] {
+			if r == '\\' && !escaped {
+				escaped = true
+				continue
+			}
+			escaped = false
+			value.WriteRune(r)
+		}
+		return value.String(), nil
+	}
+
+	switch strings.ToLower(literal) {
+	case "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	number, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, errors.Errorf("unsupported value: %s", literal)
+	}
+	return number, nil
+}
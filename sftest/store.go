@@ -0,0 +1,629 @@
+package sftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// keyPrefixes holds the ID prefixes of the standard objects. The records of the other objects get IDs prefixed with
+// "a00", like custom objects.
+var keyPrefixes = map[string]string{
+	"Account":     "001",
+	"Contact":     "003",
+	"Opportunity": "006",
+	"Lead":        "00Q",
+	"Case":        "500",
+	"User":        "005",
+	"Task":        "00T",
+	"Event":       "00U",
+}
+
+// store holds the records of the objects in memory. It is shared by Server and Fake.
+type store struct {
+	mu sync.Mutex
+	// tables holds the records of each object, by lowercase object name.
+	tables map[string]*table
+	lastID int
+}
+
+// table holds the records of an object, in creation order.
+type table struct {
+	name    string
+	records []map[string]interface{}
+	// deleted holds the deleted records, which are only returned by queryAll.
+	deleted  []map[string]interface{}
+	describe map[string]interface{}
+}
+
+// storeError is an error reported with the status and the error code salesforce reports it with.
+type storeError struct {
+	statusCode int
+	errorCode  string
+	message    string
+	fields     []string
+}
+
+func (err *storeError) Error() string {
+	return err.errorCode + ": " + err.message
+}
+
+// entry returns the error in the format of the REST API.
+func (err *storeError) entry() map[string]interface{} {
+	entry := map[string]interface{}{"errorCode": err.errorCode, "message": err.message}
+	if len(err.fields) > 0 {
+		entry["fields"] = err.fields
+	}
+	return entry
+}
+
+var (
+	// errNotFound is returned for the records and objects which don't exist.
+	errNotFound = &storeError{statusCode: http.StatusNotFound, errorCode: "NOT_FOUND", message: "The requested resource does not exist"}
+	// errEntityDeleted is returned for the deleted records.
+	errEntityDeleted = &storeError{statusCode: http.StatusNotFound, errorCode: "ENTITY_IS_DELETED", message: "entity is deleted"}
+)
+
+func newStore() *store {
+	return &store{tables: make(map[string]*table)}
+}
+
+// SetDescribe sets the describe result of the object, instead of the one built from the fields of its records. The
+// names of the "fields" of the result are then the only fields which can be queried and saved. SetDescribe panics if
+// the result can't be encoded in JSON.
+func (s *store) SetDescribe(typeName string, describe map[string]interface{}) {
+	normalized, err := normalize(describe)
+	if err != nil {
+		panic(errors.Wrapf(err, "sftest: unable to set the describe result of %s", typeName))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.table(typeName).describe = normalized
+}
+
+// Seed adds records to the store, and returns their IDs. Records without an Id are given a new one. Seeding no
+// records declares the object, so that it can be queried. Seed panics if a record can't be encoded in JSON.
+func (s *store) Seed(typeName string, records ...map[string]interface{}) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(typeName)
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		normalized, err := normalize(record)
+		if err != nil {
+			panic(errors.Wrapf(err, "sftest: unable to seed %s", typeName))
+		}
+		id, _ := normalized["Id"].(string)
+		if id == "" {
+			id = s.newID(t.name)
+			normalized["Id"] = id
+		}
+		t.records = append(t.records, normalized)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// LoadFixtures seeds the store from a JSON file holding the records of each object:
+//
+//	{"Account": [{"Name": "Acme"}], "Contact": [{"LastName": "Doe"}]}
+//
+// The objects are seeded in alphabetical order.
+func (s *store) LoadFixtures(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fixtures map[string][]map[string]interface{}
+	err = json.Unmarshal(data, &fixtures)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse fixtures %s", path)
+	}
+
+	typeNames := make([]string, 0, len(fixtures))
+	for typeName := range fixtures {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	for _, typeName := range typeNames {
+		s.Seed(typeName, fixtures[typeName]...)
+	}
+	return nil
+}
+
+// Records returns a copy of the records of the object in the store, in creation order.
+func (s *store) Records(typeName string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return nil
+	}
+	records := make([]map[string]interface{}, 0, len(t.records))
+	for _, record := range t.records {
+		records = append(records, copyRecord(record))
+	}
+	return records
+}
+
+// query runs a query, and returns the selected fields of the matching records, with their attributes. The deleted
+// records are included if all is set.
+func (s *store) query(version, q string, all bool) ([]map[string]interface{}, error) {
+	parsed, err := parseQuery(q)
+	if err != nil {
+		return nil, &storeError{statusCode: http.StatusBadRequest, errorCode: "MALFORMED_QUERY", message: err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(parsed.sObject)]
+	if !ok {
+		return nil, &storeError{statusCode: http.StatusBadRequest, errorCode: "INVALID_TYPE",
+			message: fmt.Sprintf("sObject type '%s' is not supported.", parsed.sObject)}
+	}
+	fields := parsed.fields
+	for _, cond := range parsed.conditions {
+		fields = append(fields, cond.field)
+	}
+	for _, field := range fields {
+		if !t.hasField(field) {
+			return nil, &storeError{statusCode: http.StatusBadRequest, errorCode: "INVALID_FIELD",
+				message: fmt.Sprintf("No such column '%s' on entity '%s'.", field, t.name)}
+		}
+	}
+
+	candidates := t.records
+	if all {
+		candidates = append(append([]map[string]interface{}(nil), t.records...), t.deleted...)
+	}
+	records := []map[string]interface{}{}
+	for _, record := range candidates {
+		if parsed.limit >= 0 && len(records) == parsed.limit {
+			break
+		}
+		if !t.matches(record, parsed.conditions) {
+			continue
+		}
+		projected := map[string]interface{}{"attributes": attributes(version, t.name, record)}
+		for _, field := range parsed.fields {
+			name, value := t.field(record, field)
+			projected[name] = value
+		}
+		records = append(records, projected)
+	}
+	return records, nil
+}
+
+// get returns the record of the object with the ID, with its attributes.
+func (s *store) get(version, typeName, id string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return nil, errNotFound
+	}
+	i, err := t.findID(id)
+	if err != nil {
+		return nil, err
+	}
+	record := copyRecord(t.records[i])
+	record["attributes"] = attributes(version, t.name, record)
+	return record, nil
+}
+
+// getByExternalID returns the record of the object whose field has the value, with its attributes.
+func (s *store) getByExternalID(version, typeName, field, value string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return nil, errNotFound
+	}
+	i, err := t.find(field, value)
+	if err != nil {
+		return nil, err
+	}
+	record := copyRecord(t.records[i])
+	record["attributes"] = attributes(version, t.name, record)
+	return record, nil
+}
+
+// create creates a record of the object, and returns its ID.
+func (s *store) create(typeName string, fields map[string]interface{}) (string, error) {
+	fields, err := normalize(fields)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(typeName)
+	err = t.checkFields(fields)
+	if err != nil {
+		return "", err
+	}
+	record := t.canonical(fields)
+	id := s.newID(t.name)
+	record["Id"] = id
+	t.records = append(t.records, record)
+	return id, nil
+}
+
+// update updates the fields of the record of the object with the ID.
+func (s *store) update(typeName, id string, fields map[string]interface{}) error {
+	fields, err := normalize(fields)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return errNotFound
+	}
+	i, err := t.findID(id)
+	if err != nil {
+		return err
+	}
+	return t.update(t.records[i], fields)
+}
+
+// upsert updates the record of the object whose field has the value, or creates it if there is none. It returns the
+// ID of the record, and whether it was created.
+func (s *store) upsert(typeName, field, value string, fields map[string]interface{}) (string, bool, error) {
+	fields, err := normalize(fields)
+	if err != nil {
+		return "", false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(typeName)
+	i, err := t.find(field, value)
+	switch {
+	case err == errNotFound:
+		err = t.checkFields(fields)
+		if err != nil {
+			return "", false, err
+		}
+		record := t.canonical(fields)
+		record[t.fieldName(field)] = value
+		id := s.newID(t.name)
+		record["Id"] = id
+		t.records = append(t.records, record)
+		return id, true, nil
+	case err != nil:
+		return "", false, err
+	}
+
+	record := t.records[i]
+	err = t.update(record, fields)
+	if err != nil {
+		return "", false, err
+	}
+	return record["Id"].(string), false, nil
+}
+
+// delete moves the record of the object with the ID to the recycle bin.
+func (s *store) delete(typeName, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return errNotFound
+	}
+	i, err := t.findID(id)
+	if err != nil {
+		return err
+	}
+	record := t.records[i]
+	record["IsDeleted"] = true
+	t.records = append(t.records[:i], t.records[i+1:]...)
+	t.deleted = append(t.deleted, record)
+	return nil
+}
+
+// describe returns the describe result of the object.
+func (s *store) describe(typeName string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[strings.ToLower(typeName)]
+	if !ok {
+		return nil, errNotFound
+	}
+	return t.describeResult(), nil
+}
+
+// describeGlobal returns the describeGlobal result, listing the objects of the store.
+func (s *store) describeGlobal() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.tables))
+	for key := range s.tables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sobjects := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		describe := s.tables[key].describeResult()
+		delete(describe, "fields")
+		sobjects = append(sobjects, describe)
+	}
+	return map[string]interface{}{"encoding": "UTF-8", "maxBatchSize": 200, "sobjects": sobjects}
+}
+
+// table returns the table of the object, which is created if needed. s.mu must be held.
+func (s *store) table(typeName string) *table {
+	key := strings.ToLower(typeName)
+	t, ok := s.tables[key]
+	if !ok {
+		t = &table{name: typeName}
+		s.tables[key] = t
+	}
+	return t
+}
+
+// newID returns a new 18 characters record ID for the object. s.mu must be held.
+func (s *store) newID(typeName string) string {
+	s.lastID++
+	prefix, ok := keyPrefixes[typeName]
+	if !ok {
+		prefix = "a00"
+	}
+	return fmt.Sprintf("%s%012dAAA", prefix, s.lastID)
+}
+
+// hasField checks if the field can be queried. Any field can be queried unless the describe result of the object was
+// set, as the fields missing from the records are null.
+func (t *table) hasField(name string) bool {
+	if t.describe == nil || strings.EqualFold(name, "Id") || strings.EqualFold(name, "IsDeleted") {
+		return true
+	}
+	for _, field := range t.describeFields() {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the name of the field as declared by the describe result or used by the records, field names
+// being case insensitive.
+func (t *table) fieldName(name string) string {
+	for _, field := range []string{"Id", "IsDeleted"} {
+		if strings.EqualFold(name, field) {
+			return field
+		}
+	}
+	for _, field := range t.describeFields() {
+		if strings.EqualFold(field, name) {
+			return field
+		}
+	}
+	for _, record := range t.records {
+		for key := range record {
+			if strings.EqualFold(key, name) {
+				return key
+			}
+		}
+	}
+	return name
+}
+
+// field returns the name and the value of the field of the record. IsDeleted is false unless the record was deleted.
+func (t *table) field(record map[string]interface{}, name string) (string, interface{}) {
+	name = t.fieldName(name)
+	if name == "IsDeleted" && record[name] == nil {
+		return name, false
+	}
+	return name, record[name]
+}
+
+// canonical returns a copy of the fields with their names as used by the object.
+func (t *table) canonical(fields map[string]interface{}) map[string]interface{} {
+	record := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if !strings.EqualFold(key, "Id") {
+			record[t.fieldName(key)] = value
+		}
+	}
+	return record
+}
+
+// checkFields checks that the fields can be saved.
+func (t *table) checkFields(fields map[string]interface{}) error {
+	for key := range fields {
+		if !t.hasField(key) {
+			return &storeError{statusCode: http.StatusBadRequest, errorCode: "INVALID_FIELD",
+				message: fmt.Sprintf("No such column '%s' on sobject of type %s", key, t.name), fields: []string{key}}
+		}
+	}
+	return nil
+}
+
+// update sets the fields of the record.
+func (t *table) update(record map[string]interface{}, fields map[string]interface{}) error {
+	err := t.checkFields(fields)
+	if err != nil {
+		return err
+	}
+	for key, value := range t.canonical(fields) {
+		record[key] = value
+	}
+	return nil
+}
+
+// find returns the index of the record whose field has the value. errNotFound is returned if there is none, and a
+// MULTIPLE_CHOICES error if there are several.
+func (t *table) find(name, value string) (int, error) {
+	found := -1
+	for i, record := range t.records {
+		if _, v := t.field(record, name); v != nil && fmt.Sprint(v) == value {
+			if found != -1 {
+				return -1, &storeError{statusCode: http.StatusMultipleChoices, errorCode: "MULTIPLE_CHOICES",
+					message: fmt.Sprintf("more than one record found for %s = %s", name, value)}
+			}
+			found = i
+		}
+	}
+	if found == -1 {
+		return -1, errNotFound
+	}
+	return found, nil
+}
+
+// findID returns the index of the record with the ID. errEntityDeleted is returned for the deleted records.
+func (t *table) findID(id string) (int, error) {
+	i, err := t.find("Id", id)
+	if err == errNotFound {
+		for _, record := range t.deleted {
+			if record["Id"] == id {
+				return -1, errEntityDeleted
+			}
+		}
+	}
+	return i, err
+}
+
+// matches checks if the record meets the conditions. Strings are compared case insensitively, like SOQL does.
+func (t *table) matches(record map[string]interface{}, conditions []condition) bool {
+	for _, cond := range conditions {
+		_, value := t.field(record, cond.field)
+		s, isString := value.(string)
+		expected, expectString := cond.value.(string)
+		switch {
+		case isString && expectString:
+			if !strings.EqualFold(s, expected) {
+				return false
+			}
+		case value != cond.value:
+			return false
+		}
+	}
+	return true
+}
+
+// describeFields returns the names of the fields of the describe result set for the object.
+func (t *table) describeFields() []string {
+	fields, _ := t.describe["fields"].([]interface{})
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field, ok := field.(map[string]interface{}); ok {
+			if name, ok := field["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// describeResult returns the describe result set for the object, or one built from the fields of its records.
+func (t *table) describeResult() map[string]interface{} {
+	if t.describe != nil {
+		return copyRecord(t.describe)
+	}
+
+	types := map[string]string{"Id": "id"}
+	for _, record := range t.records {
+		for key, value := range record {
+			if _, ok := types[key]; ok && value == nil {
+				continue
+			}
+			switch value.(type) {
+			case bool:
+				types[key] = "boolean"
+			case float64:
+				types[key] = "double"
+			default:
+				types[key] = "string"
+			}
+		}
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		if name != "Id" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fields := make([]interface{}, 0, len(types))
+	for _, name := range append([]string{"Id"}, names...) {
+		fields = append(fields, map[string]interface{}{
+			"name":     name,
+			"label":    name,
+			"type":     types[name],
+			"custom":   strings.HasSuffix(name, "__c"),
+			"nillable": name != "Id",
+		})
+	}
+
+	keyPrefix, ok := keyPrefixes[t.name]
+	if !ok {
+		keyPrefix = "a00"
+	}
+	return map[string]interface{}{
+		"name":       t.name,
+		"label":      t.name,
+		"keyPrefix":  keyPrefix,
+		"custom":     strings.HasSuffix(t.name, "__c"),
+		"queryable":  true,
+		"createable": true,
+		"updateable": true,
+		"deletable":  true,
+		"fields":     fields,
+	}
+}
+
+// attributes returns the attributes of a record returned by the REST API.
+func attributes(version, typeName string, record map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": typeName,
+		"url":  fmt.Sprintf("/services/data/v%s/sobjects/%s/%v", version, typeName, record["Id"]),
+	}
+}
+
+// normalize returns a copy of the record with the values JSON decoding gives, e.g. float64 for numbers, as stored
+// records are compared with the values of requests.
+func normalize(record map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(map[string]interface{})
+	err = json.Unmarshal(data, &normalized)
+	if err != nil {
+		return nil, err
+	}
+	delete(normalized, "attributes")
+	return normalized, nil
+}
+
+// copyRecord returns a shallow copy of the record.
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		copied[key] = value
+	}
+	return copied
+}
@@ -2,8 +2,8 @@ package simpleforce
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"strings"
 
@@ -46,6 +46,44 @@ type SObject map[string]interface{}
 // Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api_rest.meta/api_rest/resources_sobject_describe.htm
 type SObjectMeta map[string]interface{}
 
+// SaveResult is the result returned by salesforce when saving a record.
+type SaveResult struct {
+	// ID is the ID of the record. It may be empty for a record updated by an upsert, see SObject.UpsertWithResult.
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	// Created is set if an upsert created the record rather than updating an existing one.
+	Created bool `json:"created"`
+	// Errors lists the errors reported for a record which could not be saved, which are also available from the
+	// *APIError returned along with the result.
+	Errors []APIErrorEntry `json:"errors"`
+}
+
+// UnmarshalJSON decodes a save result, whose errors report their code as statusCode rather than errorCode.
+func (result *SaveResult) UnmarshalJSON(data []byte) error {
+	type saveResult SaveResult
+	var aux struct {
+		saveResult
+		Errors []struct {
+			APIErrorEntry
+			StatusCode string `json:"statusCode"`
+		} `json:"errors"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	*result = SaveResult(aux.saveResult)
+	result.Errors = nil
+	for _, e := range aux.Errors {
+		if e.ErrorCode == "" {
+			e.ErrorCode = e.StatusCode
+		}
+		result.Errors = append(result.Errors, e.APIErrorEntry)
+	}
+	return nil
+}
+
 // SObjectAttributes describes the basic attributes (type and url) of an SObject.
 type SObjectAttributes struct {
 	Type string `json:"type"`
@@ -55,6 +93,13 @@ type SObjectAttributes struct {
 // Describe queries the metadata of an SObject using the "describe" API.
 // Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api_rest.meta/api_rest/resources_sobject_describe.htm
 func (obj *SObject) Describe() (*SObjectMeta, error) {
+	return obj.DescribeContext(context.Background())
+}
+
+// DescribeContext queries the metadata of an SObject with the context.
+func (obj *SObject) DescribeContext(ctx context.Context) (*SObjectMeta, error) {
+	ctx = withOperation(ctx, "Describe", ResourceDescribe, obj.Type())
+
 	// Sanity chekc
 	err := obj.checkTypeClient()
 	if err != nil {
@@ -62,7 +107,7 @@ func (obj *SObject) Describe() (*SObjectMeta, error) {
 	}
 
 	url := obj.client().makeURL("sobjects/" + obj.Type() + "/describe")
-	data, err := obj.client().httpRequest(http.MethodGet, url, nil)
+	data, err := obj.client().httpRequestContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +126,13 @@ func (obj *SObject) Describe() (*SObjectMeta, error) {
 // If query is successful, the SObject is updated in-place and exact same address is returned; otherwise, nil is
 // returned if failed.
 func (obj *SObject) Get(id ...string) error {
+	return obj.GetContext(context.Background(), id...)
+}
+
+// GetContext retrieves all the data fields of an SObject with the context.
+func (obj *SObject) GetContext(ctx context.Context, id ...string) error {
+	ctx = withOperation(ctx, "Get", ResourceSObject, obj.Type())
+
 	// Sanity check
 	err := obj.checkTypeClient()
 	if err != nil {
@@ -96,12 +148,12 @@ func (obj *SObject) Get(id ...string) error {
 	}
 
 	url := obj.client().makeURL("sobjects/" + obj.Type() + "/" + oid)
-	data, err := obj.client().httpRequest(http.MethodGet, url, nil)
+	data, err := obj.client().httpRequestContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(data, obj)
+	err = obj.client().unmarshalRecords(data, obj)
 	if err != nil {
 		return err
 	}
@@ -113,6 +165,13 @@ func (obj *SObject) Get(id ...string) error {
 // If the creation is successful, the ID of the SObject instance is updated with the ID returned.
 // Ref: https://developer.salesforce.com/docs/atlas.en-us.214.0.api_rest.meta/api_rest/dome_sobject_create.htm
 func (obj *SObject) Create() error {
+	return obj.CreateContext(context.Background())
+}
+
+// CreateContext posts the JSON representation of the SObject to salesforce to create the entry with the context.
+func (obj *SObject) CreateContext(ctx context.Context) error {
+	ctx = withOperation(ctx, "Create", ResourceSObject, obj.Type())
+
 	// Sanity Check
 	err := obj.checkTypeClient()
 	if err != nil {
@@ -127,84 +186,108 @@ func (obj *SObject) Create() error {
 	}
 
 	url := obj.client().makeURL("sobjects/" + obj.Type() + "/")
-	respData, err := obj.client().httpRequest(http.MethodPost, url, bytes.NewReader(reqData))
+	statusCode, respData, err := obj.client().httpRequestWithStatus(ctx, http.MethodPost, url, bytes.NewReader(reqData))
 	if err != nil {
 		return err
 	}
 
-	// Use an anonymous struct to parse the result if any. This might need to be changed if the result should
-	// be returned to the caller in some manner, especially if the client would like to decode the errors.
-	var respVal struct {
-		ID      string   `json:"id"`
-		Success bool     `json:"success"`
-		Errors  []string `json:"errors"`
-	}
-	err = json.Unmarshal(respData, &respVal)
+	result, err := obj.parseSaveResult(statusCode, respData)
 	if err != nil {
 		return err
 	}
 
-	if !respVal.Success || respVal.ID == "" {
-		log.Println(logPrefix, "unsuccessful")
-		return errors.New(strings.Join(respVal.Errors, ", "))
-	}
-
-	obj.setID(respVal.ID)
+	obj.setID(result.ID)
 	return nil
 }
 
 // Create or Update an object based on an external ID
 func (obj *SObject) Upsert(ext_id_fname string) error {
+	return obj.UpsertContext(context.Background(), ext_id_fname)
+}
+
+// UpsertContext creates or updates an object based on an external ID with the context.
+func (obj *SObject) UpsertContext(ctx context.Context, ext_id_fname string) error {
+	_, err := obj.UpsertWithResultContext(ctx, ext_id_fname)
+	return err
+}
+
+// UpsertWithResult creates or updates an object based on an external ID, and returns the result telling whether the
+// record was created. Before API version 46.0, salesforce doesn't return the ID of an updated record: the ID of the
+// result is then the one set on the SObject, which is empty unless it was queried or set.
+func (obj *SObject) UpsertWithResult(ext_id_fname string) (*SaveResult, error) {
+	return obj.UpsertWithResultContext(context.Background(), ext_id_fname)
+}
+
+// UpsertWithResultContext creates or updates an object based on an external ID with the context, and returns the
+// result telling whether the record was created. See UpsertWithResult for the ID of an updated record.
+func (obj *SObject) UpsertWithResultContext(ctx context.Context, ext_id_fname string) (*SaveResult, error) {
+	ctx = withOperation(ctx, "Upsert", ResourceSObject, obj.Type())
+
 	// Sanity Check
 	err := obj.checkTypeClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	ext_id := obj.StringField(ext_id_fname)
 	if ext_id == "" {
-		return errors.New("external ID field not set")
+		return nil, errors.New("external ID field not set")
 	}
 
 	// Make a copy of the incoming SObject, but skip certain metadata fields as they're not understood by salesforce.
 	reqObj := obj.makeCopy()
 	reqData, err := json.Marshal(reqObj)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	url := obj.client().makeURL("sobjects/" + obj.Type() + "/" + ext_id_fname + "/" + ext_id)
-	respData, err := obj.client().httpRequest(http.MethodPatch, url, bytes.NewReader(reqData))
+	statusCode, respData, err := obj.client().httpRequestWithStatus(ctx, http.MethodPatch, url, bytes.NewReader(reqData))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Use an anonymous struct to parse the result if any. This might need to be changed if the result should
-	// be returned to the caller in some manner, especially if the client would like to decode the errors.
-	var respVal struct {
-		ID      string   `json:"id"`
-		Success bool     `json:"success"`
-		Errors  []string `json:"errors"`
-		Created bool     `json:"created"`
+	// Before API version 46.0, updating an existing record returns no content.
+	if len(bytes.TrimSpace(respData)) == 0 {
+		return &SaveResult{ID: obj.ID(), Success: true}, nil
 	}
 
-	err = json.Unmarshal(respData, &respVal)
+	result, err := obj.parseSaveResult(statusCode, respData)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	if !respVal.Success || respVal.ID == "" {
-		log.Println(logPrefix, "unsuccessful")
-		return errors.New(strings.Join(respVal.Errors, ", "))
+	obj.setID(result.ID)
+	return result, nil
+}
+
+// parseSaveResult parses the result of a create or upsert. The errors reported for the record, if any, are returned
+// as an *APIError.
+func (obj *SObject) parseSaveResult(statusCode int, respData []byte) (*SaveResult, error) {
+	var result SaveResult
+	err := json.Unmarshal(respData, &result)
+	if err != nil {
+		return nil, err
 	}
 
-	obj.setID(respVal.ID)
-	return nil
+	if !result.Success || result.ID == "" {
+		apiError := &APIError{StatusCode: statusCode, Body: respData, Errors: result.Errors}
+		obj.client().log(LogLevelDebug, "unsuccessful", "type", obj.Type(), "errors", apiError.summary())
+		return &result, apiError
+	}
+	return &result, nil
 }
 
 // Update updates SObject in place.
 // ID is required.
 func (obj *SObject) Update() error {
+	return obj.UpdateContext(context.Background())
+}
+
+// UpdateContext updates SObject in place with the context.
+func (obj *SObject) UpdateContext(ctx context.Context) error {
+	ctx = withOperation(ctx, "Update", ResourceSObject, obj.Type())
+
 	// Sanity check.
 	err := obj.checkTypeClient()
 	if err != nil {
@@ -223,18 +306,20 @@ func (obj *SObject) Update() error {
 		queryBase = "tooling/sobjects/"
 	}
 	url := obj.client().makeURL(queryBase + obj.Type() + "/" + obj.ID())
-	respData, err := obj.client().httpRequest(http.MethodPatch, url, bytes.NewReader(reqData))
-	if err != nil {
-		return err
-	}
-	log.Println(string(respData))
-
-	return nil
+	_, err = obj.client().httpRequestContext(ctx, http.MethodPatch, url, bytes.NewReader(reqData))
+	return err
 }
 
 // Delete deletes an SObject record identified by external ID. nil is returned if the operation completes successfully;
 // otherwise an error is returned
 func (obj *SObject) Delete(id ...string) error {
+	return obj.DeleteContext(context.Background(), id...)
+}
+
+// DeleteContext deletes an SObject record identified by external ID with the context.
+func (obj *SObject) DeleteContext(ctx context.Context, id ...string) error {
+	ctx = withOperation(ctx, "Delete", ResourceSObject, obj.Type())
+
 	// Sanity check
 	err := obj.checkTypeClient()
 	if err != nil {
@@ -249,8 +334,8 @@ func (obj *SObject) Delete(id ...string) error {
 		return errors.New("object id not found")
 	}
 
-	url := obj.client().makeURL("sobjects/" + obj.Type() + "/" + obj.ID())
-	_, err = obj.client().httpRequest(http.MethodDelete, url, nil)
+	url := obj.client().makeURL("sobjects/" + obj.Type() + "/" + oid)
+	_, err = obj.client().httpRequestContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -319,7 +404,7 @@ func (obj *SObject) SObjectField(typeName, key string) *SObject {
 	rIndex := strings.LastIndex(url, "/")
 	if rIndex == -1 || rIndex+1 == len(url) {
 		// hmm... this shouldn't happen, unless the URL is hand crafted.
-		log.Println(logPrefix, "invalid url,", url)
+		obj.client().log(LogLevelWarn, "invalid url", "url", url)
 		return nil
 	}
 	oid = url[rIndex+1:]
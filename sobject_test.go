@@ -1,9 +1,14 @@
 package simpleforce
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"testing"
 	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
 )
 
 func TestSObject_AttributesField(t *testing.T) {
@@ -75,7 +80,7 @@ func TestSObject_SObjectField(t *testing.T) {
 }
 
 func TestSObject_Describe(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 	meta, _ := client.SObject("Case").Describe()
 	if meta == nil {
 		t.FailNow()
@@ -87,7 +92,7 @@ func TestSObject_Describe(t *testing.T) {
 }
 
 func TestSObject_Get(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	// Search for a valid Case ID first.
 	queryResult, err := client.Query("SELECT Id,OwnerId,Subject FROM CASE")
@@ -135,7 +140,7 @@ func TestSObject_Get(t *testing.T) {
 }
 
 func TestSObject_Create(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	// Positive
 	case1 := client.SObject("Case")
@@ -193,7 +198,7 @@ func TestSObject_Create(t *testing.T) {
 }
 
 func TestSObject_Update(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	// Positive
 	c := client.SObject("Case")
@@ -208,7 +213,7 @@ func TestSObject_Update(t *testing.T) {
 }
 
 func TestSObject_Delete(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	// Positive: create a case first then delete it and verify if it is gone.
 	case1 := client.SObject("Case")
@@ -230,7 +235,7 @@ func TestSObject_Delete(t *testing.T) {
 
 // TestSObject_GetUpdate validates updating of existing records.
 func TestSObject_GetUpdate(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	// Create a new case first.
 	case1 := client.SObject("Case")
@@ -276,7 +281,7 @@ func TestSObject_SetMany(t *testing.T) {
 
 // TestSObject_Upsert
 func TestSObject_Upsert(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	c := client.SObject("Case")
 	c.Set("Subject", "TestUpsertCreate")
@@ -294,3 +299,126 @@ func TestSObject_Upsert(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSObject_CreateContext(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Case/"
+	httpmock.RegisterResponder("POST", mockURL, func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.Context().Deadline(); !ok {
+			return httpmock.NewStringResponse(500, ""), nil
+		}
+		return httpmock.NewStringResponse(201, `{"id": "500xx000000bYrEAAU", "success": true, "errors": []}`), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	case1 := client.SObject("Case")
+	case1.Set("Subject", "Case created by simpleforce")
+	if err := case1.CreateContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if case1.ID() != "500xx000000bYrEAAU" {
+		t.Fail()
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if client.SObject("Case").CreateContext(canceled) == nil {
+		t.Fail()
+	}
+}
+
+func TestSObject_Create_errors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Contact/"
+	httpmock.RegisterResponder("POST", mockURL, httpmock.NewStringResponder(201, `{"id": "", "success": false, "errors": [
+		{"statusCode": "FIELD_CUSTOM_VALIDATION_EXCEPTION", "message": "Email is required for customers", "fields": ["Email"]},
+		{"statusCode": "STRING_TOO_LONG", "message": "Last Name: data value too large", "fields": ["LastName"]}
+	]}`))
+
+	contact := client.SObject("Contact")
+	contact.Set("LastName", "Doe")
+	err := contact.Create()
+
+	var apiError *APIError
+	if !errors.As(err, &apiError) || len(apiError.Errors) != 2 || apiError.StatusCode != 201 || contact.ID() != "" {
+		t.Fatal(err)
+	}
+	if apiError.Errors[0].Fields[0] != "Email" || apiError.Errors[1].ErrorCode != "STRING_TOO_LONG" {
+		t.Fail()
+	}
+	if !errors.Is(err, ErrValidationRule) || !errors.Is(err, ErrInvalidField) {
+		t.Fail()
+	}
+}
+
+func TestSObject_UpsertWithResult(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Account/ExtId__c/"
+	httpmock.RegisterResponder("PATCH", mockURL+"new",
+		httpmock.NewStringResponder(201, `{"id": "001xx000003DGb2AAG", "success": true, "errors": [], "created": true}`))
+	httpmock.RegisterResponder("PATCH", mockURL+"existing", httpmock.NewStringResponder(204, ""))
+
+	account := client.SObject("Account")
+	account.Set("ExtId__c", "new")
+	result, err := account.UpsertWithResult("ExtId__c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Created || result.ID != "001xx000003DGb2AAG" || account.ID() != result.ID {
+		t.Fail()
+	}
+
+	account = client.SObject("Account")
+	account.Set("ExtId__c", "existing")
+	result, err = account.UpsertWithResult("ExtId__c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ID of the updated record isn't returned.
+	if result.Created || !result.Success || result.ID != "" {
+		t.Fail()
+	}
+
+	// The errors are reported with the status of the response.
+	httpmock.RegisterResponder("PATCH", mockURL+"invalid", httpmock.NewStringResponder(201, `{"id": "", "success": false, "errors": [
+		{"statusCode": "REQUIRED_FIELD_MISSING", "message": "Required fields are missing: [Name]", "fields": ["Name"]}
+	], "created": true}`))
+	account = client.SObject("Account")
+	account.Set("ExtId__c", "invalid")
+	var apiError *APIError
+	result, err = account.UpsertWithResult("ExtId__c")
+	if !errors.As(err, &apiError) || apiError.StatusCode != 201 {
+		t.Error(err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].ErrorCode != "REQUIRED_FIELD_MISSING" || result.Errors[0].Fields[0] != "Name" {
+		t.Error(result)
+	}
+}
+
+func TestSObject_Delete_id(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	deleted := 0
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/sobjects/Case/500xx000000bYrEAAU"
+	httpmock.RegisterResponder("DELETE", mockURL, func(req *http.Request) (*http.Response, error) {
+		deleted++
+		return httpmock.NewStringResponse(204, ""), nil
+	})
+
+	// The ID passed to Delete is used when the SObject has none.
+	if err := client.SObject("Case").Delete("500xx000000bYrEAAU"); err != nil || deleted != 1 {
+		t.Fatal(err)
+	}
+}
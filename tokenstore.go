@@ -0,0 +1,236 @@
+package simpleforce
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session holds a salesforce session so that it can be persisted and shared through a TokenStore.
+type Session struct {
+	AccessToken  string    `json:"accessToken"`
+	InstanceURL  string    `json:"instanceUrl"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	UserID       string    `json:"userId,omitempty"`
+	IssuedAt     time.Time `json:"issuedAt"`
+}
+
+// TokenStore persists a session. When a TokenStore is set, the client loads the stored session instead of logging in,
+// saves the session after logging in, and invalidates the stored session when it has expired.
+// Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Load returns the stored session, or nil if there is none.
+	Load() (*Session, error)
+	// Save stores the session, replacing any stored session.
+	Save(session *Session) error
+	// Invalidate removes the stored session if its access token is accessToken. A session saved by someone else in
+	// the meantime is kept.
+	Invalidate(accessToken string) error
+}
+
+// SetTokenStore sets the TokenStore consulted on login and re-authentication. Stored sessions may have expired, so it
+// is recommended to enable SetAutoReauth as well.
+func (client *Client) SetTokenStore(store TokenStore) {
+	client.tokenStore = store
+}
+
+// authenticate uses the stored session if there is one, or runs login and stores the new session otherwise. login is
+// kept to re-authenticate when the session expires.
+func (client *Client) authenticate(ctx context.Context, login func(ctx context.Context) error) error {
+	if client.tokenStore != nil {
+		session, err := client.tokenStore.Load()
+		if err != nil {
+			client.log(LogLevelWarn, "error occurred loading stored session", "error", err)
+		} else if session != nil && session.AccessToken != "" {
+			client.useSession(session)
+			client.setLogin(login)
+			client.log(LogLevelInfo, "using stored session", "userId", session.UserID)
+			return nil
+		}
+	}
+
+	err := login(ctx)
+	if err != nil {
+		return err
+	}
+	client.setLogin(login)
+	client.saveSession()
+	return nil
+}
+
+// setLogin keeps login to re-authenticate when the session expires.
+func (client *Client) setLogin(login func(ctx context.Context) error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.login = func(ctx context.Context) error {
+		return client.authenticate(ctx, login)
+	}
+}
+
+// useSession updates the session of the client with a stored session.
+func (client *Client) useSession(session *Session) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sessionID = session.AccessToken
+	client.instanceURL = session.InstanceURL
+	if session.UserID != client.user.UserID {
+		client.user = Identity{UserID: session.UserID}
+		client.identityURL = ""
+		client.identityLoaded = false
+	}
+	if session.RefreshToken != "" {
+		client.oauth.refreshToken = session.RefreshToken
+		client.oauthSession = true
+	}
+}
+
+// saveSession saves the current session of the client to the token store, if any. Failures are logged only as the
+// session itself is usable.
+func (client *Client) saveSession() {
+	if client.tokenStore == nil {
+		return
+	}
+
+	client.mu.RLock()
+	session := &Session{
+		AccessToken:  client.sessionID,
+		InstanceURL:  client.instanceURL,
+		RefreshToken: client.oauth.refreshToken,
+		UserID:       client.user.UserID,
+		IssuedAt:     time.Now(),
+	}
+	client.mu.RUnlock()
+
+	if err := client.tokenStore.Save(session); err != nil {
+		client.log(LogLevelWarn, "error occurred saving session", "error", err)
+	}
+}
+
+// MemoryTokenStore is a TokenStore keeping the session in memory, e.g. to share a session between several clients of
+// the same process.
+type MemoryTokenStore struct {
+	mu      sync.Mutex
+	session *Session
+}
+
+// NewMemoryTokenStore creates an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load returns a copy of the stored session, or nil if there is none.
+func (store *MemoryTokenStore) Load() (*Session, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.session == nil {
+		return nil, nil
+	}
+	session := *store.session
+	return &session, nil
+}
+
+// Save stores a copy of the session.
+func (store *MemoryTokenStore) Save(session *Session) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	copied := *session
+	store.session = &copied
+	return nil
+}
+
+// Invalidate removes the stored session if its access token is accessToken.
+func (store *MemoryTokenStore) Invalidate(accessToken string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.session != nil && store.session.AccessToken == accessToken {
+		store.session = nil
+	}
+	return nil
+}
+
+// FileTokenStore is a TokenStore keeping the session in a JSON file readable by the owner only, so that several
+// processes can share one session. The file is replaced atomically on save.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore creates a FileTokenStore using the file at path. The file is created on the first save.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load reads the stored session, or returns nil if the file doesn't exist.
+func (store *FileTokenStore) Load() (*Session, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.read()
+}
+
+// Save writes the session to a temporary file with 0600 permissions, then renames it over the stored file.
+func (store *FileTokenStore) Save(session *Session) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// TempFile creates the file with 0600 already; be explicit as the file holds credentials.
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), store.path)
+}
+
+// Invalidate removes the file if the stored access token is accessToken.
+func (store *FileTokenStore) Invalidate(accessToken string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	session, err := store.read()
+	if err != nil || session == nil || session.AccessToken != accessToken {
+		return err
+	}
+	err = os.Remove(store.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// read decodes the stored file, or returns nil if it doesn't exist.
+func (store *FileTokenStore) read() (*Session, error) {
+	data, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	err = json.Unmarshal(data, &session)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
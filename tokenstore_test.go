@@ -0,0 +1,101 @@
+package simpleforce
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_TokenStore_shared(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	store := NewMemoryTokenStore()
+	client1 := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client1.SetTokenStore(store)
+	client1.SetAutoReauth(true)
+	client2 := NewClient(sfURL, DefaultClientID, DefaultAPIVersion)
+	client2.SetTokenStore(store)
+	client2.SetAutoReauth(true)
+
+	logins := registerCountingLoginMock(client1)
+	registerSessionCheckingQueryMock(client1, "session2")
+
+	if err := client1.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+	// The second client picks up the stored session instead of logging in.
+	if err := client2.LoginPassword(sfUser, sfPass, sfToken); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(logins) != 1 || client2.GetSid() != "session1" || client2.GetLoc() != client1.GetLoc() {
+		t.Fail()
+	}
+
+	// The first client re-authenticates and stores the new session, which the second client then picks up.
+	if _, err := client1.Query("SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client2.Query("SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(logins) != 2 || client2.GetSid() != "session2" {
+		t.Fail()
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	store := NewMemoryTokenStore()
+	if session, err := store.Load(); session != nil || err != nil {
+		t.Fail()
+	}
+
+	_ = store.Save(&Session{AccessToken: "token1", InstanceURL: "https://na0-api.salesforce.com"})
+	_ = store.Invalidate("token0")
+	if session, _ := store.Load(); session == nil || session.AccessToken != "token1" {
+		t.Fail()
+	}
+
+	_ = store.Invalidate("token1")
+	if session, _ := store.Load(); session != nil {
+		t.Fail()
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	store := NewFileTokenStore(path)
+	if session, err := store.Load(); session != nil || err != nil {
+		t.Fail()
+	}
+
+	err := store.Save(&Session{AccessToken: "token1", InstanceURL: "https://na0-api.salesforce.com", RefreshToken: "refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fail()
+	}
+
+	// Another process sees the same session.
+	session, err := NewFileTokenStore(path).Load()
+	if err != nil || session == nil || session.AccessToken != "token1" || session.RefreshToken != "refresh" {
+		t.Fail()
+	}
+
+	_ = store.Invalidate("token0")
+	if session, _ := store.Load(); session == nil {
+		t.Fail()
+	}
+	_ = store.Invalidate("token1")
+	if session, _ := store.Load(); session != nil {
+		t.Fail()
+	}
+}
@@ -1,9 +1,9 @@
 package simpleforce
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
 )
 
@@ -30,18 +30,26 @@ func (client *Client) UnTooling() {
 
 // ExecuteAnonymous executes a body of Apex code
 func (client *Client) ExecuteAnonymous(apexBody string) (*ExecuteAnonymousResult, error) {
+	return client.ExecuteAnonymousContext(context.Background(), apexBody)
+}
+
+// ExecuteAnonymousContext executes a body of Apex code with the context
+func (client *Client) ExecuteAnonymousContext(ctx context.Context, apexBody string) (*ExecuteAnonymousResult, error) {
+	ctx = withOperation(ctx, "ExecuteAnonymous", ResourceApex, "")
+
 	if !client.isLoggedIn() {
 		return nil, ErrAuthentication
 	}
 
 	// Create the endpoint
 	formatString := "%s/services/data/v%s/tooling/executeAnonymous/?anonymousBody=%s"
-	baseURL := client.instanceURL
+	baseURL := client.GetLoc()
 	endpoint := fmt.Sprintf(formatString, baseURL, client.apiVersion, url.QueryEscape(apexBody))
 
-	data, err := client.httpRequest("GET", endpoint, nil)
+	// The Apex code may run DML, so the request must not be sent again unless it was rejected before running.
+	data, err := client.httpRequestContext(withIdempotent(ctx, false), "GET", endpoint, nil)
 	if err != nil {
-		log.Println(logPrefix, "HTTP GET request failed:", endpoint)
+		client.log(LogLevelDebug, "execute anonymous failed", "error", err)
 		return nil, err
 	}
 
@@ -5,7 +5,7 @@ import (
 )
 
 func TestClient_Tooling_Query(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	q := "SELECT Id, Name FROM Layout WHERE Name = 'Account Layout'"
 	result, err := client.Tooling().Query(q)
@@ -21,7 +21,7 @@ func TestClient_Tooling_Query(t *testing.T) {
 }
 
 func TestClient_ExecuteAnonymous(t *testing.T) {
-	client := requireClient(t, true)
+	client := requireLiveClient(t)
 
 	apexBody := "System.debug('test');"
 	result, err := client.ExecuteAnonymous(apexBody)
@@ -0,0 +1,136 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrAPIUsageLimit is returned, without sending the request, when the API usage of the org has reached the limit set
+// with SetAPIUsageLimit.
+var ErrAPIUsageLimit = errors.New("API usage limit reached")
+
+// APIUsage is the daily API usage of the org, as reported by the Sforce-Limit-Info header of the last response.
+type APIUsage struct {
+	// Used is the number of API requests made in the last 24 hours.
+	Used int
+	// Max is the daily API request limit of the org.
+	Max int
+	// UpdatedAt is the time of the response reporting the usage.
+	UpdatedAt time.Time
+}
+
+// Percent returns the used part of the daily limit, from 0 to 100.
+func (usage APIUsage) Percent() float64 {
+	if usage.Max <= 0 {
+		return 0
+	}
+	return float64(usage.Used) * 100 / float64(usage.Max)
+}
+
+// criticalKey is the context key marking calls which are sent even above the API usage limit.
+type criticalKey struct{}
+
+// WithCritical marks the calls made with the returned context as critical: they are sent even when the API usage
+// limit set with SetAPIUsageLimit has been reached.
+func WithCritical(ctx context.Context) context.Context {
+	return context.WithValue(ctx, criticalKey{}, true)
+}
+
+// isCritical checks if the context has been marked with WithCritical.
+func isCritical(ctx context.Context) bool {
+	critical, _ := ctx.Value(criticalKey{}).(bool)
+	return critical
+}
+
+// APIUsage returns the latest API usage reported by salesforce. It is zero until a response has been received.
+func (client *Client) APIUsage() APIUsage {
+	client.usageMu.Lock()
+	defer client.usageMu.Unlock()
+	return client.usage
+}
+
+// SetAPIUsageThreshold sets a callback run when the API usage reaches percent of the daily limit. The callback runs
+// once each time the usage goes from below to above the threshold, in the goroutine of the request reporting it.
+func (client *Client) SetAPIUsageThreshold(percent float64, callback func(usage APIUsage)) {
+	client.usageMu.Lock()
+	defer client.usageMu.Unlock()
+	client.usageThreshold = percent
+	client.usageCallback = callback
+	client.usageThresholdReached = false
+}
+
+// SetAPIUsageLimit makes the client refuse calls with ErrAPIUsageLimit once the API usage reaches percent of the daily
+// limit, so that some requests are left for other integrations and users. Calls made with a context marked by
+// WithCritical, as well as logins, are still sent. Limits is also sent, so that the usage it reports lets the client
+// resume calls once the usage has decreased. A percent of 0 removes the limit.
+func (client *Client) SetAPIUsageLimit(percent float64) {
+	client.usageMu.Lock()
+	defer client.usageMu.Unlock()
+	client.usageLimit = percent
+}
+
+// checkAPIUsage returns ErrAPIUsageLimit if the API usage limit has been reached and the call isn't critical. Calls to
+// the limits resource are always sent, as their response refreshes the usage.
+func (client *Client) checkAPIUsage(ctx context.Context) error {
+	client.usageMu.Lock()
+	limit, usage := client.usageLimit, client.usage
+	client.usageMu.Unlock()
+
+	op, _ := ctx.Value(operationKey{}).(operation)
+	if limit > 0 && usage.Max > 0 && usage.Percent() >= limit && !isCritical(ctx) && op.resource != ResourceLimits {
+		return errors.Wrapf(ErrAPIUsageLimit, "%d of %d daily API requests used", usage.Used, usage.Max)
+	}
+	return nil
+}
+
+// recordAPIUsage records the API usage reported by the response, and runs the threshold callback if the threshold
+// has just been reached.
+func (client *Client) recordAPIUsage(resp *http.Response) {
+	used, max, ok := parseLimitInfo(resp.Header.Get("Sforce-Limit-Info"))
+	if !ok {
+		return
+	}
+
+	client.usageMu.Lock()
+	usage := APIUsage{Used: used, Max: max, UpdatedAt: time.Now()}
+	client.usage = usage
+	callback := client.usageCallback
+	reached := client.usageThreshold > 0 && usage.Percent() >= client.usageThreshold
+	notify := reached && !client.usageThresholdReached && callback != nil
+	client.usageThresholdReached = reached
+	client.usageMu.Unlock()
+
+	if notify {
+		client.log(LogLevelWarn, "API usage threshold reached", "used", used, "max", max)
+		callback(usage)
+	}
+}
+
+// parseLimitInfo parses the api-usage entry of a Sforce-Limit-Info header, e.g. "api-usage=25/15000".
+func parseLimitInfo(header string) (used, max int, ok bool) {
+	for _, entry := range strings.Split(header, ",") {
+		entry = strings.TrimSpace(entry)
+		if !strings.HasPrefix(entry, "api-usage=") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(entry, "api-usage="), "/", 2)
+		if len(parts) != 2 {
+			return 0, 0, false
+		}
+		used, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, 0, false
+		}
+		max, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, false
+		}
+		return used, max, true
+	}
+	return 0, 0, false
+}
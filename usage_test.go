@@ -0,0 +1,86 @@
+package simpleforce
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+)
+
+func TestClient_APIUsage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := requireClient(t, true)
+	used := 80
+	mockURL := "https://na0-api.salesforce.com/services/data/v" + client.apiVersion + "/query?q=SELECT%20Id%20FROM%20Case"
+	httpmock.RegisterResponder("GET", mockURL, func(req *http.Request) (*http.Response, error) {
+		used++
+		resp := httpmock.NewStringResponse(200, `{"totalSize": 0, "done": true, "records": []}`)
+		resp.Header.Set("Sforce-Limit-Info", "api-usage="+strconv.Itoa(used)+"/100, per-app-api-usage=17/250(appName=sample)")
+		return resp, nil
+	})
+
+	if client.APIUsage().Max != 0 {
+		t.Fail()
+	}
+
+	var notified []APIUsage
+	client.SetAPIUsageThreshold(85, func(usage APIUsage) {
+		notified = append(notified, usage)
+	})
+	client.SetAPIUsageLimit(90)
+
+	for i := 0; i < 9; i++ {
+		if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if usage := client.APIUsage(); usage.Used != 89 || usage.Max != 100 || usage.UpdatedAt.IsZero() {
+		t.Fail()
+	}
+	if len(notified) != 1 || notified[0].Used != 85 {
+		t.Fail()
+	}
+
+	// The 90% reported by this call stops further calls.
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Query("SELECT Id FROM Case"); !errors.Is(err, ErrAPIUsageLimit) || used != 90 {
+		t.Fatal(err)
+	}
+	if _, err := client.QueryContext(WithCritical(context.Background()), "SELECT Id FROM Case"); err != nil || used != 91 {
+		t.Fail()
+	}
+
+	// Limits is still sent, and the usage it reports once the 24 hours window has moved on lets calls through again.
+	httpmock.RegisterResponder("GET", "https://na0-api.salesforce.com/services/data/v"+client.apiVersion+"/limits",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(200, `{"DailyApiRequests": {"Max": 100, "Remaining": 60}}`)
+			resp.Header.Set("Sforce-Limit-Info", "api-usage=40/100")
+			return resp, nil
+		})
+	if _, err := client.Limits(); err != nil || client.APIUsage().Used != 40 {
+		t.Fatal(err)
+	}
+	used = 40
+	if _, err := client.Query("SELECT Id FROM Case"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseLimitInfo(t *testing.T) {
+	if used, max, ok := parseLimitInfo("api-usage=25/15000"); !ok || used != 25 || max != 15000 {
+		t.Fail()
+	}
+	if _, _, ok := parseLimitInfo("per-app-api-usage=17/250(appName=sample)"); ok {
+		t.Fail()
+	}
+	if _, _, ok := parseLimitInfo(""); ok {
+		t.Fail()
+	}
+}